@@ -0,0 +1,13 @@
+// Command fleetctl manages the fleet from the command line. Run it without
+// arguments for its usage.
+package main
+
+import (
+	"os"
+
+	"github.com/abdeel07/backend-go-cars/fleetctl"
+)
+
+func main() {
+	os.Exit(fleetctl.Main(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/ratelimit"
+	"github.com/abdeel07/backend-go-cars/tenant"
+)
+
+// Config holds the settings of the API, read from environment variables.
+type Config struct {
+	// DatabaseDSN is the MySQL data source name (DB_DSN).
+	DatabaseDSN string
+	// HTTPAddr is the address the REST API listens on (HTTP_ADDR).
+	HTTPAddr string
+	// GRPCAddr is the address the gRPC API listens on (GRPC_ADDR).
+	GRPCAddr string
+	// LogLevel is one of debug, info, warn or error (LOG_LEVEL).
+	LogLevel string
+	// LogFormat is text or json (LOG_FORMAT).
+	LogFormat string
+	// SlowQueryThreshold is the duration above which SQL queries are logged
+	// as warnings (SLOW_QUERY_THRESHOLD, e.g. 200ms).
+	SlowQueryThreshold time.Duration
+	// ShutdownDelay is how long readiness fails before the listener closes,
+	// giving load balancers time to stop routing traffic (SHUTDOWN_DELAY).
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds the wait for in-flight requests (SHUTDOWN_TIMEOUT).
+	ShutdownTimeout time.Duration
+	// WebhookPollInterval is how often the outbox is scanned for webhook
+	// deliveries (WEBHOOK_POLL_INTERVAL).
+	WebhookPollInterval time.Duration
+	// WebhookAllowPrivate lets webhooks reach loopback, link-local and
+	// private addresses (WEBHOOK_ALLOW_PRIVATE).
+	WebhookAllowPrivate bool
+	// EventsPollInterval is how often the outbox is read for the live event
+	// stream (EVENTS_POLL_INTERVAL).
+	EventsPollInterval time.Duration
+	// EventsHeartbeat is the interval of keep-alive comments on idle event
+	// streams (EVENTS_HEARTBEAT).
+	EventsHeartbeat time.Duration
+	// EventsCommitWindow is how long the event stream waits for outbox ids
+	// skipped by transactions that have not committed yet
+	// (EVENTS_COMMIT_WINDOW).
+	EventsCommitWindow time.Duration
+	// IdempotencyTTL is how long responses to requests with an
+	// Idempotency-Key header are replayed (IDEMPOTENCY_TTL).
+	IdempotencyTTL time.Duration
+	// RateLimit is the request limit of each client on routes missing from
+	// RouteLimits (RATE_LIMIT, e.g. 600/m, or off).
+	RateLimit ratelimit.Limit
+	// RouteLimits overrides RateLimit for some routes (RATE_LIMIT_ROUTES,
+	// e.g. "POST /cars=60/m,GET /healthz=off").
+	RouteLimits map[string]ratelimit.Limit
+	// RateLimitTrustProxy identifies clients by X-Forwarded-For when the API
+	// runs behind a load balancer (RATE_LIMIT_TRUST_PROXY).
+	RateLimitTrustProxy bool
+	// RateLimitAPIKeys are the X-API-Key values limited by key instead of by
+	// address (RATE_LIMIT_API_KEYS, comma-separated).
+	RateLimitAPIKeys map[string]bool
+	// TenantTokens maps bearer tokens to their tenant (TENANT_TOKENS, e.g.
+	// "s3cret=franchise-a,0ther=franchise-b").
+	TenantTokens map[string]string
+	// TenantHeader accepts the X-Tenant-ID header from requests without a
+	// token, when no TenantTokens are configured (TENANT_HEADER).
+	TenantHeader bool
+	// DefaultTenant is the tenant of requests with neither a token nor a
+	// header; empty to refuse them, as they are once TenantTokens are set
+	// (DEFAULT_TENANT).
+	DefaultTenant string
+	// Eligibility are the checks applied to the drivers of a rental
+	// (RENTAL_REQUIRE_CUSTOMER, MIN_DRIVER_AGE, MIN_DRIVER_AGE_CATEGORIES,
+	// MIN_LICENCE_YEARS, MAX_ADDITIONAL_DRIVERS, YOUNG_DRIVER_AGE and
+	// YOUNG_DRIVER_SURCHARGE).
+	Eligibility eligibility.Rules
+	// PaymentProvider holds the deposit of rentals: none, or fake for the
+	// in-process provider of development and tests (PAYMENT_PROVIDER).
+	PaymentProvider string
+	// Deposit is the amount authorized when a car is rented (DEPOSIT_AMOUNT).
+	Deposit float64
+	// CaptureAttempts is how many times the capture of a returned rental is
+	// tried before its deposit is released (DEPOSIT_CAPTURE_ATTEMPTS).
+	CaptureAttempts int
+	// CaptureRetryInterval is how often failed deposit captures are retried
+	// (DEPOSIT_CAPTURE_INTERVAL, 0 to disable).
+	CaptureRetryInterval time.Duration
+	// LateReturnMultiplier applies to the daily rate of the days a car is
+	// returned after its planned end (LATE_RETURN_RATE_MULTIPLIER).
+	LateReturnMultiplier float64
+	// LateReturnGrace is how late a car may be returned without paying late
+	// days (LATE_RETURN_GRACE).
+	LateReturnGrace time.Duration
+	// JobPollInterval is how often the scheduler looks for due background
+	// jobs (JOB_POLL_INTERVAL).
+	JobPollInterval time.Duration
+	// JobLockTTL frees the lock of a job whose replica stopped while running
+	// it; jobs must finish within it (JOB_LOCK_TTL).
+	JobLockTTL time.Duration
+	// AdminToken is the X-Admin-Token value required by the background job
+	// routes and the metrics, which are disabled without one (ADMIN_TOKEN).
+	AdminToken string
+	// OverdueCheckInterval is how often open rentals are checked for being
+	// past their planned end (OVERDUE_CHECK_INTERVAL, 0 to disable).
+	OverdueCheckInterval time.Duration
+	// SMTPAddr is the host:port of the mail server sending email
+	// notifications, empty to send none (NOTIFY_SMTP_ADDR), with
+	// NOTIFY_SMTP_FROM, NOTIFY_SMTP_USERNAME and NOTIFY_SMTP_PASSWORD.
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPUsername string
+	SMTPPassword string
+	// SMSURL is the HTTP gateway sending text message notifications, empty
+	// to send none (NOTIFY_SMS_URL), with NOTIFY_SMS_TOKEN and NOTIFY_SMS_FROM.
+	SMSURL   string
+	SMSToken string
+	SMSFrom  string
+	// NotifySink writes the notifications of the channels without a mail
+	// server or gateway to the console, or to a file, for local use
+	// (NOTIFY_SINK: console or a path; empty for none).
+	NotifySink string
+	// NotifyTemplatesDir replaces the built-in notification templates
+	// (NOTIFY_TEMPLATES_DIR).
+	NotifyTemplatesDir string
+	// NotifyDefaultLocale is the language of customers without a locale, or
+	// with one that has no templates (NOTIFY_DEFAULT_LOCALE).
+	NotifyDefaultLocale string
+	// NotifyPollInterval is how often queued notifications are sent
+	// (NOTIFY_POLL_INTERVAL).
+	NotifyPollInterval time.Duration
+	// PickupReminderLead is how long before a reservation starts the customer
+	// is reminded of it (PICKUP_REMINDER_LEAD).
+	PickupReminderLead time.Duration
+	// PickupReminderInterval is how often reservations are checked for
+	// reminders (PICKUP_REMINDER_INTERVAL, 0 to disable).
+	PickupReminderInterval time.Duration
+}
+
+// Load reads the configuration from the environment, falling back to
+// defaults suitable for local development.
+func Load() (Config, error) {
+	cfg := Config{
+		DatabaseDSN: getEnv("DB_DSN", "root:@tcp(127.0.0.1:3306)/parking_lot?charset=utf8&parseTime=True&loc=Local"),
+		HTTPAddr:    getEnv("HTTP_ADDR", ":8080"),
+		GRPCAddr:    getEnv("GRPC_ADDR", ":9090"),
+		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		LogFormat:   getEnv("LOG_FORMAT", "text"),
+
+		SMTPAddr:            getEnv("NOTIFY_SMTP_ADDR", ""),
+		SMTPFrom:            getEnv("NOTIFY_SMTP_FROM", "no-reply@localhost"),
+		SMTPUsername:        getEnv("NOTIFY_SMTP_USERNAME", ""),
+		SMTPPassword:        getEnv("NOTIFY_SMTP_PASSWORD", ""),
+		SMSURL:              getEnv("NOTIFY_SMS_URL", ""),
+		SMSToken:            getEnv("NOTIFY_SMS_TOKEN", ""),
+		SMSFrom:             getEnv("NOTIFY_SMS_FROM", ""),
+		NotifySink:          getEnv("NOTIFY_SINK", ""),
+		NotifyTemplatesDir:  getEnv("NOTIFY_TEMPLATES_DIR", ""),
+		NotifyDefaultLocale: getEnv("NOTIFY_DEFAULT_LOCALE", "en"),
+	}
+
+	durations := []struct {
+		name     string
+		fallback string
+		target   *time.Duration
+	}{
+		{"SLOW_QUERY_THRESHOLD", "200ms", &cfg.SlowQueryThreshold},
+		{"SHUTDOWN_DELAY", "5s", &cfg.ShutdownDelay},
+		{"SHUTDOWN_TIMEOUT", "15s", &cfg.ShutdownTimeout},
+		{"WEBHOOK_POLL_INTERVAL", "5s", &cfg.WebhookPollInterval},
+		{"EVENTS_POLL_INTERVAL", "1s", &cfg.EventsPollInterval},
+		{"EVENTS_HEARTBEAT", "15s", &cfg.EventsHeartbeat},
+		{"EVENTS_COMMIT_WINDOW", "1m", &cfg.EventsCommitWindow},
+		{"IDEMPOTENCY_TTL", "24h", &cfg.IdempotencyTTL},
+		{"LATE_RETURN_GRACE", "1h", &cfg.LateReturnGrace},
+		{"JOB_POLL_INTERVAL", "30s", &cfg.JobPollInterval},
+		{"JOB_LOCK_TTL", "10m", &cfg.JobLockTTL},
+		{"OVERDUE_CHECK_INTERVAL", "5m", &cfg.OverdueCheckInterval},
+		{"DEPOSIT_CAPTURE_INTERVAL", "1m", &cfg.CaptureRetryInterval},
+		{"NOTIFY_POLL_INTERVAL", "10s", &cfg.NotifyPollInterval},
+		{"PICKUP_REMINDER_LEAD", "24h", &cfg.PickupReminderLead},
+		{"PICKUP_REMINDER_INTERVAL", "15m", &cfg.PickupReminderInterval},
+	}
+	for _, d := range durations {
+		value, err := time.ParseDuration(getEnv(d.name, d.fallback))
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", d.name, err)
+		}
+		*d.target = value
+	}
+
+	var err error
+	if cfg.RateLimit, err = ratelimit.ParseLimit(getEnv("RATE_LIMIT", "600/m")); err != nil {
+		return cfg, fmt.Errorf("RATE_LIMIT: %w", err)
+	}
+	cfg.RouteLimits, err = ratelimit.ParseRouteLimits(getEnv("RATE_LIMIT_ROUTES",
+		"POST /cars=60/m,GET /cars=300/m,GET /healthz=off,GET /readyz=off,GET /metrics=off"))
+	if err != nil {
+		return cfg, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+	}
+	if cfg.RateLimitTrustProxy, err = strconv.ParseBool(getEnv("RATE_LIMIT_TRUST_PROXY", "false")); err != nil {
+		return cfg, fmt.Errorf("RATE_LIMIT_TRUST_PROXY: %w", err)
+	}
+	cfg.RateLimitAPIKeys = ratelimit.ParseAPIKeys(getEnv("RATE_LIMIT_API_KEYS", ""))
+
+	// Tokens are secrets: report which setting is wrong, not its value.
+	if cfg.TenantTokens, err = tenant.ParseTokens(getEnv("TENANT_TOKENS", "")); err != nil {
+		return cfg, fmt.Errorf("TENANT_TOKENS: %w", err)
+	}
+	if cfg.TenantHeader, err = strconv.ParseBool(getEnv("TENANT_HEADER", "false")); err != nil {
+		return cfg, fmt.Errorf("TENANT_HEADER: %w", err)
+	}
+	cfg.AdminToken = getEnv("ADMIN_TOKEN", "")
+	if cfg.WebhookAllowPrivate, err = strconv.ParseBool(getEnv("WEBHOOK_ALLOW_PRIVATE", "false")); err != nil {
+		return cfg, fmt.Errorf("WEBHOOK_ALLOW_PRIVATE: %w", err)
+	}
+	defaultTenant := tenant.DefaultID
+	if len(cfg.TenantTokens) > 0 {
+		defaultTenant = ""
+	}
+	cfg.DefaultTenant = getEnv("DEFAULT_TENANT", defaultTenant)
+	if cfg.DefaultTenant != "" && !tenant.ValidID(cfg.DefaultTenant) {
+		return cfg, fmt.Errorf("DEFAULT_TENANT: %w", tenant.ErrInvalidID)
+	}
+
+	cfg.Eligibility = eligibility.DefaultRules()
+	if cfg.Eligibility.RequireDriver, err = strconv.ParseBool(getEnv("RENTAL_REQUIRE_CUSTOMER", "false")); err != nil {
+		return cfg, fmt.Errorf("RENTAL_REQUIRE_CUSTOMER: %w", err)
+	}
+	integers := []struct {
+		name   string
+		target *int
+	}{
+		{"MIN_DRIVER_AGE", &cfg.Eligibility.MinAge},
+		{"MIN_LICENCE_YEARS", &cfg.Eligibility.MinLicenceYears},
+		{"MAX_ADDITIONAL_DRIVERS", &cfg.Eligibility.MaxAdditionalDrivers},
+		{"YOUNG_DRIVER_AGE", &cfg.Eligibility.YoungDriverAge},
+	}
+	for _, i := range integers {
+		if value, ok := os.LookupEnv(i.name); ok {
+			if *i.target, err = strconv.Atoi(value); err != nil {
+				return cfg, fmt.Errorf("%s: %w", i.name, err)
+			}
+		}
+	}
+	if cfg.Eligibility.MinAgeByCategory, err = eligibility.ParseCategoryAges(getEnv("MIN_DRIVER_AGE_CATEGORIES", "")); err != nil {
+		return cfg, fmt.Errorf("MIN_DRIVER_AGE_CATEGORIES: %w", err)
+	}
+	if cfg.Eligibility.YoungDriverSurcharge, err = strconv.ParseFloat(getEnv("YOUNG_DRIVER_SURCHARGE", "0"), 64); err != nil {
+		return cfg, fmt.Errorf("YOUNG_DRIVER_SURCHARGE: %w", err)
+	}
+
+	cfg.PaymentProvider = getEnv("PAYMENT_PROVIDER", "none")
+	if cfg.PaymentProvider != "none" && cfg.PaymentProvider != "fake" {
+		return cfg, fmt.Errorf("PAYMENT_PROVIDER: unknown provider %q", cfg.PaymentProvider)
+	}
+	if cfg.Deposit, err = strconv.ParseFloat(getEnv("DEPOSIT_AMOUNT", "300"), 64); err != nil {
+		return cfg, fmt.Errorf("DEPOSIT_AMOUNT: %w", err)
+	}
+	if cfg.CaptureAttempts, err = strconv.Atoi(getEnv("DEPOSIT_CAPTURE_ATTEMPTS", "5")); err != nil || cfg.CaptureAttempts < 1 {
+		return cfg, fmt.Errorf("DEPOSIT_CAPTURE_ATTEMPTS: must be a positive integer")
+	}
+	if cfg.LateReturnMultiplier, err = strconv.ParseFloat(getEnv("LATE_RETURN_RATE_MULTIPLIER", "1.5"), 64); err != nil {
+		return cfg, fmt.Errorf("LATE_RETURN_RATE_MULTIPLIER: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func getEnv(name, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return fallback
+}
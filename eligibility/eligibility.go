@@ -0,0 +1,218 @@
+// Package eligibility decides whether the drivers named on a rental agreement
+// may take a car: their age for its category, how long they have held their
+// licence, how many of them there are and whether they are blocked. Each
+// failed rule is reported, so the agent can tell the customer everything
+// that is missing at once.
+package eligibility
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// Rule names a check, as reported in failures.
+type Rule string
+
+const (
+	RuleDriverRequired    Rule = "driver_required"
+	RuleMinAge            Rule = "min_age"
+	RuleLicenceTenure     Rule = "licence_tenure"
+	RuleAdditionalDrivers Rule = "additional_drivers"
+	RuleBlocklist         Rule = "blocklist"
+)
+
+// Driver is a person on a rental agreement, the customer or an additional
+// driver.
+type Driver struct {
+	CustomerID      uint
+	DateOfBirth     *time.Time
+	LicenceIssuedAt *time.Time
+	// Blocked is the reason the driver is on the blocklist, if any.
+	Blocked string
+}
+
+// Failure is a rule a rental does not satisfy. CustomerID is zero for rules
+// about the agreement as a whole.
+type Failure struct {
+	Rule       Rule   `json:"rule"`
+	CustomerID uint   `json:"customer_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+var ErrNotEligible = errors.New("drivers are not eligible for this rental")
+
+// Error lists the rules a rental failed.
+type Error struct {
+	Failures []Failure
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, len(e.Failures))
+	for i, failure := range e.Failures {
+		messages[i] = failure.Message
+	}
+	return ErrNotEligible.Error() + ": " + strings.Join(messages, "; ")
+}
+
+// Is makes errors.Is(err, ErrNotEligible) match every Error.
+func (e *Error) Is(target error) bool {
+	return target == ErrNotEligible
+}
+
+// Rules are the conditions checked when a car is rented. Zero values disable
+// a rule.
+type Rules struct {
+	// RequireDriver refuses rentals that name no customer.
+	RequireDriver bool
+	// MinAge is the minimum age of every driver.
+	MinAge int
+	// MinAgeByCategory raises MinAge for the categories matching an ACRISS
+	// pattern, where * matches any letter (e.g. "L***" for luxury cars).
+	MinAgeByCategory map[string]int
+	// MinLicenceYears is how long every driver must have held a licence.
+	MinLicenceYears int
+	// MaxAdditionalDrivers is the number of drivers allowed besides the
+	// customer; negative to allow none.
+	MaxAdditionalDrivers int
+	// YoungDriverAge is the age under which the young driver surcharge is
+	// added to the daily rate.
+	YoungDriverAge int
+	// YoungDriverSurcharge is charged per day when a driver is younger than
+	// YoungDriverAge.
+	YoungDriverSurcharge float64
+}
+
+// DefaultRules returns the rules applied unless configured otherwise.
+func DefaultRules() Rules {
+	return Rules{
+		MinAge:               21,
+		MinLicenceYears:      1,
+		MaxAdditionalDrivers: 3,
+		YoungDriverAge:       25,
+	}
+}
+
+// minAge returns the minimum age for a car category.
+func (r Rules) minAge(category string) int {
+	minimum := r.MinAge
+	for pattern, age := range r.MinAgeByCategory {
+		if age > minimum && matchCategory(pattern, category) {
+			minimum = age
+		}
+	}
+	return minimum
+}
+
+// matchCategory reports whether an ACRISS pattern matches a category.
+func matchCategory(pattern, category string) bool {
+	if len(pattern) != len(category) {
+		return false
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != model.CategoryWildcard && pattern[i] != category[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns the rules failed by the drivers of a rental of a car of the
+// given category, the customer first. It returns an *Error, or nil if they
+// are eligible.
+func (r Rules) Check(drivers []Driver, category string, at time.Time) error {
+	var failures []Failure
+	if len(drivers) == 0 {
+		if r.RequireDriver {
+			failures = append(failures, Failure{Rule: RuleDriverRequired, Message: "a customer is required to rent a car"})
+		}
+	} else if limit := max(r.MaxAdditionalDrivers, 0); r.MaxAdditionalDrivers != 0 && len(drivers)-1 > limit {
+		failures = append(failures, Failure{
+			Rule:    RuleAdditionalDrivers,
+			Message: fmt.Sprintf("at most %d additional drivers are allowed", limit),
+		})
+	}
+
+	minAge := r.minAge(category)
+	for _, driver := range drivers {
+		fail := func(rule Rule, format string, args ...interface{}) {
+			failures = append(failures, Failure{Rule: rule, CustomerID: driver.CustomerID, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if driver.Blocked != "" {
+			fail(RuleBlocklist, "driver %d is on the blocklist: %s", driver.CustomerID, driver.Blocked)
+		}
+		if minAge > 0 {
+			if driver.DateOfBirth == nil {
+				fail(RuleMinAge, "driver %d has no date of birth", driver.CustomerID)
+			} else if age := yearsBetween(*driver.DateOfBirth, at); age < minAge {
+				fail(RuleMinAge, "driver %d is %d, the minimum age for category %s is %d", driver.CustomerID, age, category, minAge)
+			}
+		}
+		if r.MinLicenceYears > 0 {
+			if driver.LicenceIssuedAt == nil {
+				fail(RuleLicenceTenure, "driver %d has no licence date", driver.CustomerID)
+			} else if years := yearsBetween(*driver.LicenceIssuedAt, at); years < r.MinLicenceYears {
+				fail(RuleLicenceTenure, "driver %d has held a licence for %d years, %d are required", driver.CustomerID, years, r.MinLicenceYears)
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return &Error{Failures: failures}
+	}
+	return nil
+}
+
+// Surcharge returns the daily young driver surcharge of a rental: it is
+// charged once if any driver is younger than YoungDriverAge.
+func (r Rules) Surcharge(drivers []Driver, at time.Time) float64 {
+	if r.YoungDriverAge <= 0 {
+		return 0
+	}
+	for _, driver := range drivers {
+		if driver.DateOfBirth != nil && yearsBetween(*driver.DateOfBirth, at) < r.YoungDriverAge {
+			return r.YoungDriverSurcharge
+		}
+	}
+	return 0
+}
+
+// yearsBetween returns the number of full years from since to at.
+func yearsBetween(since, at time.Time) int {
+	years := at.Year() - since.Year()
+	if at.Month() < since.Month() || (at.Month() == since.Month() && at.Day() < since.Day()) {
+		years--
+	}
+	return years
+}
+
+// ParseCategoryAges parses a comma separated list of "pattern=age" pairs,
+// such as "L***=25,X***=25".
+func ParseCategoryAges(text string) (map[string]int, error) {
+	ages := map[string]int{}
+	for _, entry := range strings.Split(text, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, errors.New("invalid category age, expected pattern=age")
+		}
+		pattern, err := model.NormalizeCategory(pattern, true)
+		if err != nil {
+			return nil, err
+		}
+		age, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || age < 0 {
+			return nil, errors.New("invalid category age, expected a positive number of years")
+		}
+		ages[pattern] = age
+	}
+	return ages, nil
+}
@@ -0,0 +1,294 @@
+// Package events streams fleet changes to live subscribers. The broker tails
+// the outbox table, so every instance of the API sees the changes made by the
+// others, and keeps the latest events in memory to let clients resume.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// Event is a fleet change as sent to subscribers.
+type Event struct {
+	ID           uint
+	Type         string
+	Tenant       string
+	Registration string
+	Branch       string
+	Data         []byte
+}
+
+// Filter selects the events of a subscription. Empty fields match everything.
+type Filter struct {
+	Tenant       string
+	Registration string
+	Branch       string
+}
+
+// Match reports whether the event passes the filter. Registrations are
+// compared in their canonical form.
+func (f Filter) Match(event Event) bool {
+	return (f.Tenant == "" || f.Tenant == event.Tenant) &&
+		(f.Registration == "" || model.CanonicalRegistration(f.Registration) == model.CanonicalRegistration(event.Registration)) &&
+		(f.Branch == "" || f.Branch == event.Branch)
+}
+
+// subscriberBuffer is the number of events a subscriber may lag behind before
+// it is disconnected.
+const subscriberBuffer = 64
+
+// Subscription receives the events matching its filter on C. C is closed when
+// the subscriber falls too far behind or the broker stops.
+type Subscription struct {
+	C      <-chan Event
+	filter Filter
+	events chan Event
+}
+
+// maxGaps bounds the skipped outbox ids the broker keeps waiting for.
+const maxGaps = 1000
+
+// Broker polls the outbox and publishes new events to its subscriptions.
+//
+// Outbox ids are allocated when a transaction writes its event but become
+// visible when it commits, so a poll may see an id before a smaller one. The
+// ids skipped this way are polled again for CommitWindow, after which their
+// transaction is assumed to have rolled back.
+type Broker struct {
+	DB           *gorm.DB
+	Logger       *slog.Logger
+	Heartbeat    time.Duration
+	CommitWindow time.Duration
+
+	mu            sync.Mutex
+	buffer        []Event
+	size          int
+	lastID        uint
+	evictedID     uint
+	gaps          map[uint]time.Time
+	loaded        bool
+	stopped       bool
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker that keeps the last size events for resumes.
+func NewBroker(db *gorm.DB, logger *slog.Logger, size int) *Broker {
+	return &Broker{
+		DB:            db,
+		Logger:        logger,
+		Heartbeat:     15 * time.Second,
+		CommitWindow:  time.Minute,
+		size:          size,
+		gaps:          make(map[uint]time.Time),
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Run polls the outbox every interval until ctx is cancelled, then closes
+// every subscription.
+func (b *Broker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Poll(ctx); err != nil && ctx.Err() == nil {
+			b.Logger.Error("Failed to poll fleet events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			b.stop()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll reads the outbox events written since the last poll, and those of
+// the skipped ids that committed since, and publishes them. The first poll
+// fills the buffer with the latest events without publishing them.
+func (b *Broker) Poll(ctx context.Context) error {
+	b.mu.Lock()
+	lastID, loaded := b.lastID, b.loaded
+	now := time.Now()
+	gaps := make([]uint, 0, len(b.gaps))
+	for id, seen := range b.gaps {
+		if now.Sub(seen) > b.CommitWindow {
+			delete(b.gaps, id)
+			continue
+		}
+		gaps = append(gaps, id)
+	}
+	b.mu.Unlock()
+
+	var rows []model.OutboxEvent
+	query := b.DB.WithContext(ctx)
+	switch {
+	case !loaded:
+		query = query.Order("id DESC").Limit(b.size)
+	case len(gaps) > 0:
+		query = query.Where("id > ? OR id IN ?", lastID, gaps).Order("id").Limit(500)
+	default:
+		query = query.Where("id > ?", lastID).Order("id").Limit(500)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return err
+	}
+	if !loaded {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.loaded && len(rows) == b.size && b.size > 0 {
+		// Older events may exist but were not loaded.
+		b.evictedID = rows[0].ID - 1
+	}
+	for _, row := range rows {
+		if row.ID <= b.lastID {
+			if _, ok := b.gaps[row.ID]; !ok {
+				continue
+			}
+			delete(b.gaps, row.ID)
+		} else {
+			b.skip(row.ID, now)
+			b.lastID = row.ID
+		}
+
+		event := decode(row)
+		b.insert(event)
+		if loaded {
+			b.publish(event)
+		}
+	}
+	b.loaded = true
+
+	return nil
+}
+
+// skip records the ids between the last one read and id as gaps to poll
+// again. b.mu must be held.
+func (b *Broker) skip(id uint, now time.Time) {
+	if b.lastID == 0 {
+		return
+	}
+	from := b.lastID + 1
+	if id-from > maxGaps {
+		from = id - maxGaps
+	}
+	for gap := from; gap < id && len(b.gaps) < maxGaps; gap++ {
+		b.gaps[gap] = now
+	}
+}
+
+// insert adds the event to the buffer in id order, evicting the oldest
+// events beyond its size. b.mu must be held.
+func (b *Broker) insert(event Event) {
+	i := sort.Search(len(b.buffer), func(i int) bool { return b.buffer[i].ID > event.ID })
+	b.buffer = append(b.buffer, Event{})
+	copy(b.buffer[i+1:], b.buffer[i:])
+	b.buffer[i] = event
+
+	if len(b.buffer) > b.size {
+		if evicted := b.buffer[len(b.buffer)-b.size-1].ID; evicted > b.evictedID {
+			b.evictedID = evicted
+		}
+		b.buffer = b.buffer[len(b.buffer)-b.size:]
+	}
+}
+
+// decode extracts the car of an outbox event for filtering.
+func decode(row model.OutboxEvent) Event {
+	var payload struct {
+		Car model.Car `json:"car"`
+	}
+	json.Unmarshal([]byte(row.Payload), &payload)
+
+	return Event{
+		ID:           row.ID,
+		Type:         row.Type,
+		Tenant:       row.TenantID,
+		Registration: payload.Car.Registration,
+		Branch:       payload.Car.Branch,
+		Data:         []byte(row.Payload),
+	}
+}
+
+// publish sends the event to the matching subscriptions. b.mu must be held.
+func (b *Broker) publish(event Event) {
+	for subscription := range b.subscriptions {
+		if !subscription.filter.Match(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			// The subscriber cannot keep up; it will resume from its last event.
+			b.remove(subscription)
+		}
+	}
+}
+
+// Subscribe registers a subscription. When resume is set, the buffered
+// events after lastID are returned to be sent first; complete is false if
+// some events after lastID have already left the buffer.
+func (b *Broker) Subscribe(filter Filter, lastID uint, resume bool) (subscription *Subscription, replay []Event, complete bool) {
+	events := make(chan Event, subscriberBuffer)
+	subscription = &Subscription{C: events, filter: filter, events: events}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		close(events)
+		return subscription, nil, true
+	}
+	b.subscriptions[subscription] = struct{}{}
+
+	if !resume {
+		return subscription, nil, true
+	}
+
+	complete = lastID >= b.evictedID
+	for _, event := range b.buffer {
+		if event.ID > lastID && filter.Match(event) {
+			replay = append(replay, event)
+		}
+	}
+
+	return subscription, replay, complete
+}
+
+// Unsubscribe removes the subscription and closes its channel.
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(subscription)
+}
+
+// remove closes a subscription once. b.mu must be held.
+func (b *Broker) remove(subscription *Subscription) {
+	if _, ok := b.subscriptions[subscription]; ok {
+		delete(b.subscriptions, subscription)
+		close(subscription.events)
+	}
+}
+
+// stop closes every subscription so that open streams end.
+func (b *Broker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	for subscription := range b.subscriptions {
+		b.remove(subscription)
+	}
+}
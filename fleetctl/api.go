@@ -0,0 +1,195 @@
+package fleetctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/tenant"
+)
+
+// APIBackend sends the operations to the REST API.
+type APIBackend struct {
+	// BaseURL is the root of the API, e.g. http://localhost:8080.
+	BaseURL string
+	Client  *http.Client
+	// Token is sent as a bearer token, and Tenant as the X-Tenant-ID header.
+	Token  string
+	Tenant string
+}
+
+// NewAPIBackend creates a backend for the API at baseURL.
+func NewAPIBackend(baseURL string) *APIBackend {
+	return &APIBackend{BaseURL: strings.TrimRight(baseURL, "/"), Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// APIError is an error response of the API.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, unless out is nil.
+func (b *APIBackend) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, b.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if b.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+	if b.Tenant != "" {
+		request.Header.Set(tenant.Header, b.Tenant)
+	}
+
+	response, err := b.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(response.Body).Decode(&failure)
+		if failure.Error == "" {
+			failure.Error = http.StatusText(response.StatusCode)
+		}
+		return &APIError{StatusCode: response.StatusCode, Message: failure.Error}
+	}
+	if out == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
+
+// carResponse is the body of the rental endpoints.
+type carResponse struct {
+	Car    model.Car     `json:"car"`
+	Rental *model.Rental `json:"rental"`
+}
+
+func (r carResponse) values() (model.Car, model.Rental) {
+	if r.Rental == nil {
+		return r.Car, model.Rental{}
+	}
+	return r.Car, *r.Rental
+}
+
+func (b *APIBackend) ListCars(ctx context.Context, filter CarFilter) ([]model.Car, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"make": filter.Make, "model": filter.CarModel, "category": filter.Category,
+		"branch": filter.Branch, "status": string(filter.Status),
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if filter.Available != nil {
+		query.Set("available", strconv.FormatBool(*filter.Available))
+	}
+
+	var cars []model.Car
+	err := b.do(ctx, http.MethodGet, "/cars?"+query.Encode(), nil, &cars)
+	return cars, err
+}
+
+func (b *APIBackend) AddCar(ctx context.Context, car *model.Car) error {
+	return b.do(ctx, http.MethodPost, "/cars", car, car)
+}
+
+func (b *APIBackend) RentCar(ctx context.Context, registration string, customerID *uint) (model.Car, model.Rental, error) {
+	var response carResponse
+	body := map[string]interface{}{"customer_id": customerID}
+	err := b.do(ctx, http.MethodPut, "/cars/"+url.PathEscape(registration)+"/rentals", body, &response)
+	car, rental := response.values()
+	return car, rental, err
+}
+
+func (b *APIBackend) RentByCategory(ctx context.Context, category, strategy string, customerID *uint) (model.Car, model.Rental, error) {
+	var response carResponse
+	body := map[string]interface{}{"category": category, "strategy": strategy, "customer_id": customerID}
+	err := b.do(ctx, http.MethodPost, "/rentals", body, &response)
+	car, rental := response.values()
+	return car, rental, err
+}
+
+func (b *APIBackend) ReturnCar(ctx context.Context, registration string, odometer float64) (model.Car, model.Rental, error) {
+	var response carResponse
+	body := map[string]interface{}{"odometer": odometer}
+	err := b.do(ctx, http.MethodPut, "/cars/"+url.PathEscape(registration)+"/returns", body, &response)
+	car, rental := response.values()
+	return car, rental, err
+}
+
+func (b *APIBackend) DeleteCar(ctx context.Context, registration string) error {
+	return b.do(ctx, http.MethodDelete, "/cars/"+url.PathEscape(registration), nil, nil)
+}
+
+func (b *APIBackend) Report(ctx context.Context, name string, query ReportQuery) (interface{}, error) {
+	values := url.Values{}
+	if !query.From.IsZero() {
+		values.Set("from", query.From.Format(time.RFC3339))
+	}
+	if !query.To.IsZero() {
+		values.Set("to", query.To.Format(time.RFC3339))
+	}
+
+	path := "/reports/" + name
+	switch name {
+	case "utilization":
+		if query.GroupBy != "" {
+			values.Set("group_by", query.GroupBy)
+		}
+		var rows []service.UtilizationRow
+		err := b.do(ctx, http.MethodGet, path+"?"+values.Encode(), nil, &rows)
+		return rows, err
+	case "rentals":
+		var rows []service.RentalStats
+		err := b.do(ctx, http.MethodGet, path+"?"+values.Encode(), nil, &rows)
+		return rows, err
+	case "idle":
+		values = url.Values{}
+		if query.Days > 0 {
+			values.Set("days", strconv.Itoa(query.Days))
+		}
+		var rows []service.IdleCarRow
+		err := b.do(ctx, http.MethodGet, path+"?"+values.Encode(), nil, &rows)
+		return rows, err
+	case "revenue":
+		var rows []service.RevenueRow
+		err := b.do(ctx, http.MethodGet, path+"?"+values.Encode(), nil, &rows)
+		return rows, err
+	default:
+		return nil, ErrUnknownReport
+	}
+}
@@ -0,0 +1,65 @@
+// Package fleetctl implements the fleetctl command line tool, which runs the
+// fleet operations either through the REST API or directly on the database.
+package fleetctl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// CarFilter selects the cars listed. Empty fields match every car.
+type CarFilter struct {
+	Make      string
+	CarModel  string
+	Category  string
+	Branch    string
+	Status    model.CarStatus
+	Available *bool
+}
+
+// ReportQuery holds the parameters of a report. From and To bound the range
+// of the utilization, rentals and revenue reports; Days is the idle period of
+// the idle report.
+type ReportQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+	Days    int
+}
+
+// Reports lists the reports that can be printed.
+var Reports = []string{"utilization", "rentals", "idle", "revenue"}
+
+// ErrUnknownReport is returned for a report name missing from Reports.
+var ErrUnknownReport = errors.New("unknown report, expected utilization, rentals, idle or revenue")
+
+// Backend runs the fleet operations. APIBackend sends them to the REST API
+// and DBBackend runs them on the database through the service layer.
+type Backend interface {
+	ListCars(ctx context.Context, filter CarFilter) ([]model.Car, error)
+	AddCar(ctx context.Context, car *model.Car) error
+	RentCar(ctx context.Context, registration string, customerID *uint) (model.Car, model.Rental, error)
+	RentByCategory(ctx context.Context, category, strategy string, customerID *uint) (model.Car, model.Rental, error)
+	ReturnCar(ctx context.Context, registration string, odometer float64) (model.Car, model.Rental, error)
+	DeleteCar(ctx context.Context, registration string) error
+	// Report returns the rows of a report: a slice of service.UtilizationRow,
+	// service.RentalStats, service.IdleCarRow or service.RevenueRow.
+	Report(ctx context.Context, name string, query ReportQuery) (interface{}, error)
+}
+
+// reportRange returns the range of a report, the last 30 days by default as
+// in the REST API.
+func (q ReportQuery) reportRange() service.ReportRange {
+	period := service.ReportRange{From: q.From, To: q.To}
+	if period.To.IsZero() {
+		period.To = time.Now()
+	}
+	if period.From.IsZero() {
+		period.From = period.To.AddDate(0, 0, -30)
+	}
+	return period
+}
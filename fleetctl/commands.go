@@ -0,0 +1,385 @@
+package fleetctl
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `Usage: fleetctl [-api URL | -dsn DSN] [-tenant ID] [-token TOKEN] [-o table|json] <command> [arguments]
+
+Commands:
+  list [-make M] [-model M] [-category C] [-branch B] [-status S] [-available true|false]
+  add -registration R -model M [-make M] [-year Y] [-category C] [-branch B] [-mileage N] [-daily-rate N] ...
+  rent <registration> [-customer ID]
+  rent -category C [-strategy lowest_mileage|round_robin|service_due] [-customer ID]
+  return <registration> -odometer N
+  delete <registration>
+  import <file.csv | ->
+  report <utilization|rentals|idle|revenue> [-from DATE] [-to DATE] [-group-by day|week|month] [-days N]
+  migrate
+
+The REST API is used when -api or FLEETCTL_API is set, the database named by
+-dsn or DB_DSN otherwise.
+`
+
+// carColumns are the car fields printed in tables.
+var carColumns = []string{"registration", "model", "make", "category", "branch", "status", "mileage", "daily_rate"}
+
+// RentalResult is printed by the rent and return commands.
+type RentalResult struct {
+	Car    model.Car    `json:"car"`
+	Rental model.Rental `json:"rental"`
+}
+
+// rentalRow is the table row of a RentalResult.
+type rentalRow struct {
+	Registration string          `json:"registration"`
+	Status       model.CarStatus `json:"status"`
+	Rental       uint            `json:"rental"`
+	StartedAt    time.Time       `json:"started_at"`
+	EndedAt      *time.Time      `json:"ended_at"`
+	Distance     float64         `json:"distance"`
+	Amount       float64         `json:"amount"`
+}
+
+func (p Printer) printRental(car model.Car, rental model.Rental) error {
+	if p.JSON {
+		return p.Print(RentalResult{Car: car, Rental: rental})
+	}
+	return p.Print(rentalRow{
+		Registration: car.Registration,
+		Status:       car.Status,
+		Rental:       rental.ID,
+		StartedAt:    rental.StartedAt,
+		EndedAt:      rental.EndedAt,
+		Distance:     rental.Distance,
+		Amount:       rental.Amount,
+	})
+}
+
+// Main runs fleetctl with the given arguments and returns its exit status.
+func Main(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("fleetctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.Usage = func() { fmt.Fprint(stderr, usage) }
+	api := global.String("api", os.Getenv("FLEETCTL_API"), "base URL of the REST API")
+	dsn := global.String("dsn", os.Getenv("DB_DSN"), "database DSN")
+	tenantID := global.String("tenant", os.Getenv("FLEETCTL_TENANT"), "tenant to act for")
+	token := global.String("token", os.Getenv("FLEETCTL_TOKEN"), "bearer token sent to the REST API")
+	output := global.String("o", "table", "output format, table or json")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 || (*output != "table" && *output != "json") {
+		global.Usage()
+		return 2
+	}
+
+	var backend Backend
+	var db *gorm.DB
+	if *api != "" {
+		client := NewAPIBackend(*api)
+		client.Token, client.Tenant = *token, *tenantID
+		backend = client
+	} else {
+		if *dsn == "" {
+			fmt.Fprintln(stderr, "fleetctl: set -api or -dsn")
+			return 2
+		}
+		var err error
+		db, err = service.InitializeDB(*dsn, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			fmt.Fprintln(stderr, "fleetctl: opening database:", err)
+			return 1
+		}
+		backend = &DBBackend{Service: service.NewParkingLotService(db), Tenant: *tenantID}
+	}
+
+	printer := Printer{Out: stdout, JSON: *output == "json"}
+	var err error
+	if global.Arg(0) == "migrate" {
+		if db == nil {
+			err = errors.New("migrate needs a database, not the REST API")
+		} else {
+			err = service.MigrateDB(db)
+			if errors.Is(err, service.ErrRegistrationCollisions) {
+				err = reportCollisions(db, stdout)
+			} else if err == nil {
+				fmt.Fprintln(stdout, "Database migrated")
+			}
+		}
+	} else {
+		err = Run(context.Background(), backend, printer, global.Args())
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "fleetctl:", err)
+		if errors.Is(err, flag.ErrHelp) || errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// reportCollisions lists the cars sharing a registration once compared in
+// its canonical form, failing if there are any.
+func reportCollisions(db *gorm.DB, stdout io.Writer) error {
+	collisions, err := service.RegistrationCollisions(context.Background(), db)
+	if err != nil || len(collisions) == 0 {
+		return err
+	}
+
+	for _, collision := range collisions {
+		fmt.Fprintf(stdout, "Tenant %s: %s are the same registration %s\n", collision.TenantID,
+			strings.Join(collision.Registrations, ", "), collision.CanonicalRegistration)
+	}
+	return fmt.Errorf("%d registrations are shared by several cars, correct them before using these cars", len(collisions))
+}
+
+// errUsage is returned for commands called with the wrong arguments.
+var errUsage = errors.New("invalid arguments")
+
+// parse parses the flags of a command, which may come before or after its
+// positional arguments, and returns the positional arguments.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", flags.Name(), err)
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// Run runs a command other than migrate, named by args[0], on backend.
+func Run(ctx context.Context, backend Backend, printer Printer, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+
+	switch args[0] {
+	case "list":
+		var filter CarFilter
+		var status, available string
+		flags.StringVar(&filter.Make, "make", "", "")
+		flags.StringVar(&filter.CarModel, "model", "", "")
+		flags.StringVar(&filter.Category, "category", "", "")
+		flags.StringVar(&filter.Branch, "branch", "", "")
+		flags.StringVar(&status, "status", "", "")
+		flags.StringVar(&available, "available", "", "")
+		if _, err := parse(flags, args[1:]); err != nil {
+			return err
+		}
+		filter.Status = model.CarStatus(status)
+		if available != "" {
+			value, err := strconv.ParseBool(available)
+			if err != nil {
+				return fmt.Errorf("list: -available must be true or false: %w", errUsage)
+			}
+			filter.Available = &value
+		}
+
+		cars, err := backend.ListCars(ctx, filter)
+		if err != nil {
+			return err
+		}
+		return printer.Print(cars, carColumns...)
+
+	case "add":
+		var car model.Car
+		var purchaseDate string
+		flags.StringVar(&car.Registration, "registration", "", "")
+		flags.StringVar(&car.CarModel, "model", "", "")
+		flags.StringVar(&car.Make, "make", "", "")
+		flags.IntVar(&car.Year, "year", 0, "")
+		flags.StringVar(&car.VIN, "vin", "", "")
+		flags.StringVar(&car.Colour, "colour", "", "")
+		flags.IntVar(&car.Seats, "seats", 0, "")
+		flags.StringVar((*string)(&car.Transmission), "transmission", "", "")
+		flags.StringVar((*string)(&car.FuelType), "fuel-type", "", "")
+		flags.StringVar(&car.Category, "category", "", "")
+		flags.StringVar(&car.Branch, "branch", "", "")
+		flags.Float64Var(&car.Mileage, "mileage", 0, "")
+		flags.Float64Var(&car.NextServiceMileage, "next-service-mileage", 0, "")
+		flags.Float64Var(&car.DailyRate, "daily-rate", 0, "")
+		flags.Float64Var(&car.PurchasePrice, "purchase-price", 0, "")
+		flags.StringVar(&purchaseDate, "purchase-date", "", "")
+		if _, err := parse(flags, args[1:]); err != nil {
+			return err
+		}
+		if purchaseDate != "" {
+			if err := csvColumns["purchase_date"](&car, purchaseDate); err != nil {
+				return fmt.Errorf("add: -purchase-date %s", err)
+			}
+		}
+
+		if err := backend.AddCar(ctx, &car); err != nil {
+			return err
+		}
+		return printer.Print(car, carColumns...)
+
+	case "rent":
+		var category, strategy string
+		var customer uint
+		flags.StringVar(&category, "category", "", "")
+		flags.StringVar(&strategy, "strategy", "", "")
+		flags.UintVar(&customer, "customer", 0, "")
+		positional, err := parse(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		var customerID *uint
+		if customer != 0 {
+			customerID = &customer
+		}
+
+		var car model.Car
+		var rental model.Rental
+		switch {
+		case len(positional) == 1 && category == "":
+			car, rental, err = backend.RentCar(ctx, positional[0], customerID)
+		case len(positional) == 0 && category != "":
+			car, rental, err = backend.RentByCategory(ctx, category, strategy, customerID)
+		default:
+			return fmt.Errorf("rent: give a registration or -category: %w", errUsage)
+		}
+		if err != nil {
+			return err
+		}
+		return printer.printRental(car, rental)
+
+	case "return":
+		odometer := flags.Float64("odometer", -1, "")
+		positional, err := parse(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 || *odometer < 0 {
+			return fmt.Errorf("return: give a registration and -odometer: %w", errUsage)
+		}
+
+		car, rental, err := backend.ReturnCar(ctx, positional[0], *odometer)
+		if err != nil {
+			return err
+		}
+		return printer.printRental(car, rental)
+
+	case "delete":
+		positional, err := parse(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("delete: give a registration: %w", errUsage)
+		}
+
+		if err := backend.DeleteCar(ctx, positional[0]); err != nil {
+			return err
+		}
+		if printer.JSON {
+			return printer.Print(map[string]string{"deleted": positional[0]})
+		}
+		_, err = fmt.Fprintf(printer.Out, "Car %s deleted\n", positional[0])
+		return err
+
+	case "import":
+		positional, err := parse(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("import: give a CSV file, or - for the standard input: %w", errUsage)
+		}
+
+		input := io.Reader(os.Stdin)
+		if positional[0] != "-" {
+			file, err := os.Open(positional[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+
+		results, err := ImportCars(ctx, backend, input)
+		if err != nil {
+			return err
+		}
+		if err := printer.Print(results); err != nil {
+			return err
+		}
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d cars not imported", failed, len(results))
+		}
+		return nil
+
+	case "report":
+		var query ReportQuery
+		var from, to string
+		flags.StringVar(&from, "from", "", "")
+		flags.StringVar(&to, "to", "", "")
+		flags.StringVar(&query.GroupBy, "group-by", "", "")
+		flags.IntVar(&query.Days, "days", 0, "")
+		positional, err := parse(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("report: give a report name: %w", errUsage)
+		}
+		if query.From, err = parseDate(from); err != nil {
+			return fmt.Errorf("report: -from %w", err)
+		}
+		if query.To, err = parseDate(to); err != nil {
+			return fmt.Errorf("report: -to %w", err)
+		}
+
+		rows, err := backend.Report(ctx, positional[0], query)
+		if err != nil {
+			return err
+		}
+		return printer.Print(rows)
+	}
+
+	return fmt.Errorf("unknown command %q: %w", args[0], errUsage)
+}
+
+// parseDate parses a report bound given as a date or an RFC 3339 time.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be a date (2006-01-02) or an RFC 3339 time")
+	}
+	return t, nil
+}
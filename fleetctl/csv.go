@@ -0,0 +1,122 @@
+package fleetctl
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// csvColumns sets the car field of each column accepted by ImportCars.
+var csvColumns = map[string]func(car *model.Car, value string) error{
+	"registration": func(car *model.Car, value string) error { car.Registration = value; return nil },
+	"model":        func(car *model.Car, value string) error { car.CarModel = value; return nil },
+	"make":         func(car *model.Car, value string) error { car.Make = value; return nil },
+	"vin":          func(car *model.Car, value string) error { car.VIN = value; return nil },
+	"colour":       func(car *model.Car, value string) error { car.Colour = value; return nil },
+	"category":     func(car *model.Car, value string) error { car.Category = value; return nil },
+	"branch":       func(car *model.Car, value string) error { car.Branch = value; return nil },
+	"transmission": func(car *model.Car, value string) error { car.Transmission = model.Transmission(value); return nil },
+	"fuel_type":    func(car *model.Car, value string) error { car.FuelType = model.FuelType(value); return nil },
+	"year":         func(car *model.Car, value string) error { return parseInt(value, &car.Year) },
+	"seats":        func(car *model.Car, value string) error { return parseInt(value, &car.Seats) },
+	"mileage":      func(car *model.Car, value string) error { return parseFloat(value, &car.Mileage) },
+	"next_service_mileage": func(car *model.Car, value string) error {
+		return parseFloat(value, &car.NextServiceMileage)
+	},
+	"daily_rate":     func(car *model.Car, value string) error { return parseFloat(value, &car.DailyRate) },
+	"purchase_price": func(car *model.Car, value string) error { return parseFloat(value, &car.PurchasePrice) },
+	"purchase_date": func(car *model.Car, value string) error {
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return errors.New("must be a date (2006-01-02)")
+		}
+		car.PurchaseDate = &date
+		return nil
+	},
+}
+
+func parseInt(value string, target *int) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("must be an integer")
+	}
+	*target = parsed
+	return nil
+}
+
+func parseFloat(value string, target *float64) error {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.New("must be a number")
+	}
+	*target = parsed
+	return nil
+}
+
+// ImportResult is the outcome of importing one line of a CSV file.
+type ImportResult struct {
+	Line         int    `json:"line"`
+	Registration string `json:"registration"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ImportCars adds the cars of a CSV file, whose header names the columns
+// (registration and model are required, the other columns of a car are
+// optional). A line that cannot be read or added is reported and the import
+// continues with the next one.
+func ImportCars(ctx context.Context, backend Backend, r io.Reader) ([]ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	setters := make([]func(*model.Car, string) error, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		header[i] = column
+		if setters[i] = csvColumns[column]; setters[i] == nil {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+	}
+
+	var results []ImportResult
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return results, nil
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			results = append(results, ImportResult{Line: line, Error: err.Error()})
+			continue
+		}
+
+		var car model.Car
+		result := ImportResult{Line: line}
+		for i, value := range record {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			if err := setters[i](&car, value); err != nil {
+				result.Error = header[i] + " " + err.Error()
+				break
+			}
+		}
+		result.Registration = car.Registration
+		if result.Error == "" {
+			if err := backend.AddCar(ctx, &car); err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+}
@@ -0,0 +1,102 @@
+package fleetctl
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/tenant"
+)
+
+// DBBackend runs the operations on the database through the service layer.
+// Without a Tenant it sees the rows of every tenant, and creates cars for the
+// default one.
+type DBBackend struct {
+	Service *service.ParkingLotService
+	Tenant  string
+}
+
+// context scopes ctx to the tenant of the backend, if any.
+func (b *DBBackend) context(ctx context.Context) context.Context {
+	if b.Tenant == "" {
+		return ctx
+	}
+	return tenant.WithID(ctx, b.Tenant)
+}
+
+func (b *DBBackend) ListCars(ctx context.Context, filter CarFilter) ([]model.Car, error) {
+	carFilter := service.CarFilter{
+		Make:      filter.Make,
+		CarModel:  filter.CarModel,
+		Branch:    filter.Branch,
+		Status:    filter.Status,
+		Available: filter.Available,
+	}
+	if filter.Category != "" {
+		pattern, err := model.NormalizeCategory(filter.Category, true)
+		if err != nil {
+			return nil, err
+		}
+		carFilter.Category = pattern
+	}
+	return b.Service.ListCars(b.context(ctx), carFilter)
+}
+
+func (b *DBBackend) AddCar(ctx context.Context, car *model.Car) error {
+	if message := model.ValidateCar(car); message != "" {
+		return errors.New(message)
+	}
+	return b.Service.AddCar(b.context(ctx), car)
+}
+
+func (b *DBBackend) RentCar(ctx context.Context, registration string, customerID *uint) (model.Car, model.Rental, error) {
+	return b.Service.RentCar(b.context(ctx), registration, service.RentOptions{CustomerID: customerID})
+}
+
+func (b *DBBackend) RentByCategory(ctx context.Context, category, strategy string, customerID *uint) (model.Car, model.Rental, error) {
+	pattern, err := model.NormalizeCategory(category, true)
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+	allocation, err := service.ParseAllocationStrategy(strategy)
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+	return b.Service.RentByCategory(b.context(ctx), pattern, allocation, service.RentOptions{CustomerID: customerID})
+}
+
+func (b *DBBackend) ReturnCar(ctx context.Context, registration string, odometer float64) (model.Car, model.Rental, error) {
+	return b.Service.ReturnCar(b.context(ctx), registration, odometer)
+}
+
+func (b *DBBackend) DeleteCar(ctx context.Context, registration string) error {
+	return b.Service.DeleteCar(b.context(ctx), registration)
+}
+
+func (b *DBBackend) Report(ctx context.Context, name string, query ReportQuery) (interface{}, error) {
+	ctx = b.context(ctx)
+	period := query.reportRange()
+
+	switch name {
+	case "utilization":
+		if query.GroupBy == "model" {
+			return b.Service.UtilizationByModel(ctx, period)
+		}
+		return b.Service.UtilizationByCar(ctx, period)
+	case "rentals":
+		stats, err := b.Service.RentalStatistics(ctx, period)
+		return []service.RentalStats{stats}, err
+	case "idle":
+		days := query.Days
+		if days <= 0 {
+			days = 30
+		}
+		return b.Service.IdleCars(ctx, time.Now().AddDate(0, 0, -days))
+	case "revenue":
+		return b.Service.RevenueByCar(ctx, period)
+	default:
+		return nil, ErrUnknownReport
+	}
+}
@@ -0,0 +1,126 @@
+package fleetctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Printer writes results as an aligned table or as indented JSON.
+type Printer struct {
+	Out  io.Writer
+	JSON bool
+}
+
+// Print writes a struct or a slice of structs. Tables have one row per
+// struct and one column per JSON field, restricted to columns if any are
+// given; nested structs other than times are left out.
+func (p Printer) Print(value interface{}, columns ...string) error {
+	if p.JSON {
+		encoder := json.NewEncoder(p.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	rows := reflect.Indirect(reflect.ValueOf(value))
+	if rows.Kind() != reflect.Slice {
+		rows = reflect.Append(reflect.MakeSlice(reflect.SliceOf(rows.Type()), 0, 1), rows)
+	}
+	if len(columns) == 0 {
+		columns = fieldNames(rows.Type().Elem())
+	}
+
+	writer := tabwriter.NewWriter(p.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(columns, "\t")))
+	for i := 0; i < rows.Len(); i++ {
+		fields := jsonFields(reflect.Indirect(rows.Index(i)))
+		cells := make([]string, len(columns))
+		for j, column := range columns {
+			cells[j] = fields[column]
+		}
+		fmt.Fprintln(writer, strings.Join(cells, "\t"))
+	}
+	return writer.Flush()
+}
+
+// jsonName returns the JSON name of a struct field, or "" if it is skipped.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" || !field.IsExported() {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// printable reports whether a field type is shown in tables.
+func printable(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == timeType || (t.Kind() != reflect.Struct && t.Kind() != reflect.Slice && t.Kind() != reflect.Map)
+}
+
+// fieldNames lists the printable JSON fields of a struct type, without those
+// of embedded structs such as gorm.Model.
+func fieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := jsonName(field); name != "" && !field.Anonymous && printable(field.Type) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonFields formats the fields of a struct value by JSON name, including
+// those of embedded structs.
+func jsonFields(value reflect.Value) map[string]string {
+	fields := map[string]string{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name, cell := range jsonFields(value.Field(i)) {
+				fields[name] = cell
+			}
+			continue
+		}
+		if name := jsonName(field); name != "" && printable(field.Type) {
+			fields[name] = format(value.Field(i))
+		}
+	}
+	return fields
+}
+
+// format renders a field value in a table cell.
+func format(value reflect.Value) string {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "-"
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.Struct:
+		if t, ok := value.Interface().(time.Time); ok {
+			if t.IsZero() {
+				return "-"
+			}
+			return t.Local().Format("2006-01-02 15:04")
+		}
+	}
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,413 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: fleet.proto
+
+package fleetpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	FleetService_AddCar_FullMethodName    = "/fleet.v1.FleetService/AddCar"
+	FleetService_GetCar_FullMethodName    = "/fleet.v1.FleetService/GetCar"
+	FleetService_ListCars_FullMethodName  = "/fleet.v1.FleetService/ListCars"
+	FleetService_RentCar_FullMethodName   = "/fleet.v1.FleetService/RentCar"
+	FleetService_ReturnCar_FullMethodName = "/fleet.v1.FleetService/ReturnCar"
+	FleetService_DeleteCar_FullMethodName = "/fleet.v1.FleetService/DeleteCar"
+	FleetService_WatchCars_FullMethodName = "/fleet.v1.FleetService/WatchCars"
+)
+
+// FleetServiceClient is the client API for FleetService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// FleetService manages the cars of the parking lot. It shares the service
+// layer of the REST API.
+type FleetServiceClient interface {
+	// AddCar adds a car to the fleet.
+	AddCar(ctx context.Context, in *AddCarRequest, opts ...grpc.CallOption) (*Car, error)
+	// GetCar returns a car by registration.
+	GetCar(ctx context.Context, in *GetCarRequest, opts ...grpc.CallOption) (*Car, error)
+	// ListCars streams the cars matching the filter.
+	ListCars(ctx context.Context, in *ListCarsRequest, opts ...grpc.CallOption) (FleetService_ListCarsClient, error)
+	// RentCar rents a car by registration, or any car of a category.
+	RentCar(ctx context.Context, in *RentCarRequest, opts ...grpc.CallOption) (*RentalResponse, error)
+	// ReturnCar returns a rented car with its odometer reading.
+	ReturnCar(ctx context.Context, in *ReturnCarRequest, opts ...grpc.CallOption) (*RentalResponse, error)
+	// DeleteCar retires and deletes a car.
+	DeleteCar(ctx context.Context, in *DeleteCarRequest, opts ...grpc.CallOption) (*DeleteCarResponse, error)
+	// WatchCars streams the changes of the fleet as they happen.
+	WatchCars(ctx context.Context, in *WatchCarsRequest, opts ...grpc.CallOption) (FleetService_WatchCarsClient, error)
+}
+
+type fleetServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFleetServiceClient(cc grpc.ClientConnInterface) FleetServiceClient {
+	return &fleetServiceClient{cc}
+}
+
+func (c *fleetServiceClient) AddCar(ctx context.Context, in *AddCarRequest, opts ...grpc.CallOption) (*Car, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Car)
+	err := c.cc.Invoke(ctx, FleetService_AddCar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetServiceClient) GetCar(ctx context.Context, in *GetCarRequest, opts ...grpc.CallOption) (*Car, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Car)
+	err := c.cc.Invoke(ctx, FleetService_GetCar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetServiceClient) ListCars(ctx context.Context, in *ListCarsRequest, opts ...grpc.CallOption) (FleetService_ListCarsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FleetService_ServiceDesc.Streams[0], FleetService_ListCars_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fleetServiceListCarsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FleetService_ListCarsClient interface {
+	Recv() (*Car, error)
+	grpc.ClientStream
+}
+
+type fleetServiceListCarsClient struct {
+	grpc.ClientStream
+}
+
+func (x *fleetServiceListCarsClient) Recv() (*Car, error) {
+	m := new(Car)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *fleetServiceClient) RentCar(ctx context.Context, in *RentCarRequest, opts ...grpc.CallOption) (*RentalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RentalResponse)
+	err := c.cc.Invoke(ctx, FleetService_RentCar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetServiceClient) ReturnCar(ctx context.Context, in *ReturnCarRequest, opts ...grpc.CallOption) (*RentalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RentalResponse)
+	err := c.cc.Invoke(ctx, FleetService_ReturnCar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetServiceClient) DeleteCar(ctx context.Context, in *DeleteCarRequest, opts ...grpc.CallOption) (*DeleteCarResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCarResponse)
+	err := c.cc.Invoke(ctx, FleetService_DeleteCar_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fleetServiceClient) WatchCars(ctx context.Context, in *WatchCarsRequest, opts ...grpc.CallOption) (FleetService_WatchCarsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &FleetService_ServiceDesc.Streams[1], FleetService_WatchCars_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fleetServiceWatchCarsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FleetService_WatchCarsClient interface {
+	Recv() (*CarEvent, error)
+	grpc.ClientStream
+}
+
+type fleetServiceWatchCarsClient struct {
+	grpc.ClientStream
+}
+
+func (x *fleetServiceWatchCarsClient) Recv() (*CarEvent, error) {
+	m := new(CarEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FleetServiceServer is the server API for FleetService service.
+// All implementations must embed UnimplementedFleetServiceServer
+// for forward compatibility
+//
+// FleetService manages the cars of the parking lot. It shares the service
+// layer of the REST API.
+type FleetServiceServer interface {
+	// AddCar adds a car to the fleet.
+	AddCar(context.Context, *AddCarRequest) (*Car, error)
+	// GetCar returns a car by registration.
+	GetCar(context.Context, *GetCarRequest) (*Car, error)
+	// ListCars streams the cars matching the filter.
+	ListCars(*ListCarsRequest, FleetService_ListCarsServer) error
+	// RentCar rents a car by registration, or any car of a category.
+	RentCar(context.Context, *RentCarRequest) (*RentalResponse, error)
+	// ReturnCar returns a rented car with its odometer reading.
+	ReturnCar(context.Context, *ReturnCarRequest) (*RentalResponse, error)
+	// DeleteCar retires and deletes a car.
+	DeleteCar(context.Context, *DeleteCarRequest) (*DeleteCarResponse, error)
+	// WatchCars streams the changes of the fleet as they happen.
+	WatchCars(*WatchCarsRequest, FleetService_WatchCarsServer) error
+	mustEmbedUnimplementedFleetServiceServer()
+}
+
+// UnimplementedFleetServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedFleetServiceServer struct {
+}
+
+func (UnimplementedFleetServiceServer) AddCar(context.Context, *AddCarRequest) (*Car, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCar not implemented")
+}
+func (UnimplementedFleetServiceServer) GetCar(context.Context, *GetCarRequest) (*Car, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCar not implemented")
+}
+func (UnimplementedFleetServiceServer) ListCars(*ListCarsRequest, FleetService_ListCarsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListCars not implemented")
+}
+func (UnimplementedFleetServiceServer) RentCar(context.Context, *RentCarRequest) (*RentalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RentCar not implemented")
+}
+func (UnimplementedFleetServiceServer) ReturnCar(context.Context, *ReturnCarRequest) (*RentalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReturnCar not implemented")
+}
+func (UnimplementedFleetServiceServer) DeleteCar(context.Context, *DeleteCarRequest) (*DeleteCarResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCar not implemented")
+}
+func (UnimplementedFleetServiceServer) WatchCars(*WatchCarsRequest, FleetService_WatchCarsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCars not implemented")
+}
+func (UnimplementedFleetServiceServer) mustEmbedUnimplementedFleetServiceServer() {}
+
+// UnsafeFleetServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FleetServiceServer will
+// result in compilation errors.
+type UnsafeFleetServiceServer interface {
+	mustEmbedUnimplementedFleetServiceServer()
+}
+
+func RegisterFleetServiceServer(s grpc.ServiceRegistrar, srv FleetServiceServer) {
+	s.RegisterService(&FleetService_ServiceDesc, srv)
+}
+
+func _FleetService_AddCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).AddCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetService_AddCar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).AddCar(ctx, req.(*AddCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetService_GetCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).GetCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetService_GetCar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).GetCar(ctx, req.(*GetCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetService_ListCars_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListCarsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FleetServiceServer).ListCars(m, &fleetServiceListCarsServer{ServerStream: stream})
+}
+
+type FleetService_ListCarsServer interface {
+	Send(*Car) error
+	grpc.ServerStream
+}
+
+type fleetServiceListCarsServer struct {
+	grpc.ServerStream
+}
+
+func (x *fleetServiceListCarsServer) Send(m *Car) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _FleetService_RentCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RentCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).RentCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetService_RentCar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).RentCar(ctx, req.(*RentCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetService_ReturnCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReturnCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).ReturnCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetService_ReturnCar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).ReturnCar(ctx, req.(*ReturnCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetService_DeleteCar_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FleetServiceServer).DeleteCar(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FleetService_DeleteCar_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FleetServiceServer).DeleteCar(ctx, req.(*DeleteCarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FleetService_WatchCars_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCarsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FleetServiceServer).WatchCars(m, &fleetServiceWatchCarsServer{ServerStream: stream})
+}
+
+type FleetService_WatchCarsServer interface {
+	Send(*CarEvent) error
+	grpc.ServerStream
+}
+
+type fleetServiceWatchCarsServer struct {
+	grpc.ServerStream
+}
+
+func (x *fleetServiceWatchCarsServer) Send(m *CarEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// FleetService_ServiceDesc is the grpc.ServiceDesc for FleetService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FleetService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fleet.v1.FleetService",
+	HandlerType: (*FleetServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCar",
+			Handler:    _FleetService_AddCar_Handler,
+		},
+		{
+			MethodName: "GetCar",
+			Handler:    _FleetService_GetCar_Handler,
+		},
+		{
+			MethodName: "RentCar",
+			Handler:    _FleetService_RentCar_Handler,
+		},
+		{
+			MethodName: "ReturnCar",
+			Handler:    _FleetService_ReturnCar_Handler,
+		},
+		{
+			MethodName: "DeleteCar",
+			Handler:    _FleetService_DeleteCar_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListCars",
+			Handler:       _FleetService_ListCars_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchCars",
+			Handler:       _FleetService_WatchCars_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "fleet.proto",
+}
@@ -0,0 +1,4 @@
+// Package fleetpb holds the Go code generated from proto/fleet.proto.
+package fleetpb
+
+//go:generate protoc -I ../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative fleet.proto
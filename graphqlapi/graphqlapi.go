@@ -0,0 +1,83 @@
+// Package graphqlapi serves the cars, rentals and customers of the fleet as a
+// GraphQL API on top of the same service layer as the REST handlers.
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxDepth bounds the nesting of queries such as cars.rentals.car.rentals...
+const maxDepth = 8
+
+// Resolver is the root resolver of the queries and mutations.
+type Resolver struct {
+	service *service.ParkingLotService
+	logger  *slog.Logger
+}
+
+// NewHandler returns the HTTP handler serving GraphQL requests.
+func NewHandler(s *server.Server) http.Handler {
+	resolver := &Resolver{service: s.ParkingLotService, logger: s.Logger}
+	return &relay.Handler{Schema: graphql.MustParseSchema(schema, resolver, graphql.MaxDepth(maxDepth))}
+}
+
+// resolverError is an error reported to the client with a code in its
+// extensions.
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions adds the error code to the GraphQL error.
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// badInput reports an invalid argument.
+func badInput(message string) error {
+	return &resolverError{message: message, code: "BAD_USER_INPUT"}
+}
+
+// fail converts a service error to a resolver error, the same way the REST
+// handlers map them to HTTP statuses. Internal errors are logged and their
+// details hidden from the client.
+func (r *Resolver) fail(ctx context.Context, message string, err error) error {
+	switch {
+	case errors.Is(err, service.ErrCarNotFound), errors.Is(err, service.ErrRentalNotFound),
+		errors.Is(err, service.ErrCustomerNotFound):
+		return &resolverError{message: err.Error(), code: "NOT_FOUND"}
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrNoCarAvailable),
+		errors.Is(err, service.ErrVersionMismatch), errors.Is(err, service.ErrReservationConflict),
+		errors.Is(err, service.ErrRentalClosed):
+		return &resolverError{message: err.Error(), code: "CONFLICT"}
+	case errors.Is(err, eligibility.ErrNotEligible):
+		return &resolverError{message: err.Error(), code: "NOT_ELIGIBLE"}
+	case errors.Is(err, payment.ErrDeclined), errors.Is(err, service.ErrPaymentMethodRequired):
+		return &resolverError{message: err.Error(), code: "PAYMENT_REQUIRED"}
+	case errors.Is(err, service.ErrOdometerRollback), errors.Is(err, service.ErrUnknownStrategy),
+		errors.Is(err, model.ErrInvalidCategory), errors.Is(err, service.ErrInvalidPeriod):
+		return badInput(err.Error())
+	default:
+		r.logger.ErrorContext(ctx, message, "error", err)
+		return &resolverError{message: message, code: "INTERNAL"}
+	}
+}
@@ -0,0 +1,163 @@
+package graphqlapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// rentalPayload resolves the RentalPayload type.
+type rentalPayload struct {
+	car    *carResolver
+	rental *rentalResolver
+}
+
+func (p *rentalPayload) Car() *carResolver       { return p.car }
+func (p *rentalPayload) Rental() *rentalResolver { return p.rental }
+
+// newPayload wraps the result of a rent or return.
+func (r *Resolver) newPayload(car model.Car, rental model.Rental) *rentalPayload {
+	return &rentalPayload{car: r.newCars([]model.Car{car})[0], rental: r.newRentals([]model.Rental{rental})[0]}
+}
+
+// RentCar rents the car with the registration, or any car of the category.
+func (r *Resolver) RentCar(ctx context.Context, args struct {
+	Registration        *string
+	Category            *string
+	Strategy            *string
+	CustomerId          *graphql.ID
+	AdditionalDriverIds *[]graphql.ID
+	PaymentMethod       *string
+	PlannedEndAt        *graphql.Time
+}) (*rentalPayload, error) {
+	var opts service.RentOptions
+	if args.PaymentMethod != nil {
+		opts.PaymentMethod = *args.PaymentMethod
+	}
+	if args.PlannedEndAt != nil {
+		opts.PlannedEndAt = &args.PlannedEndAt.Time
+	}
+	if args.CustomerId != nil {
+		id, err := parseID(*args.CustomerId)
+		if err != nil {
+			return nil, err
+		}
+		opts.CustomerID = &id
+	}
+	if args.AdditionalDriverIds != nil {
+		for _, driver := range *args.AdditionalDriverIds {
+			id, err := parseID(driver)
+			if err != nil {
+				return nil, err
+			}
+			opts.AdditionalDriverIDs = append(opts.AdditionalDriverIDs, id)
+		}
+	}
+
+	var car model.Car
+	var rental model.Rental
+	var err error
+	switch {
+	case args.Registration != nil:
+		car, rental, err = r.service.RentCar(ctx, *args.Registration, opts)
+	case args.Category != nil:
+		pattern, categoryErr := model.NormalizeCategory(*args.Category, true)
+		if categoryErr != nil {
+			return nil, badInput("category must be a valid ACRISS code")
+		}
+		name := ""
+		if args.Strategy != nil {
+			name = *args.Strategy
+		}
+		strategy, strategyErr := service.ParseAllocationStrategy(name)
+		if strategyErr != nil {
+			return nil, badInput("strategy must be one of lowest_mileage, round_robin or service_due")
+		}
+		car, rental, err = r.service.RentByCategory(ctx, pattern, strategy, opts)
+	default:
+		return nil, badInput("registration or category is required")
+	}
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to rent car", err)
+	}
+
+	return r.newPayload(car, rental), nil
+}
+
+// ReturnCar returns a rented car with its absolute odometer reading.
+func (r *Resolver) ReturnCar(ctx context.Context, args struct {
+	Registration string
+	Odometer     float64
+}) (*rentalPayload, error) {
+	if args.Odometer < 0 {
+		return nil, badInput("odometer reading must be positive")
+	}
+
+	car, rental, err := r.service.ReturnCar(ctx, args.Registration, args.Odometer)
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to return car", err)
+	}
+
+	return r.newPayload(car, rental), nil
+}
+
+// ExtendRental moves the planned end of a rental in progress later.
+func (r *Resolver) ExtendRental(ctx context.Context, args struct {
+	ID           graphql.ID
+	PlannedEndAt graphql.Time
+}) (*rentalResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rental, err := r.service.ExtendRental(ctx, id, args.PlannedEndAt.Time)
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to extend rental", err)
+	}
+
+	return r.newRentals([]model.Rental{rental})[0], nil
+}
+
+// AddCustomer registers a customer.
+func (r *Resolver) AddCustomer(ctx context.Context, args struct {
+	Name            string
+	Email           *string
+	Phone           *string
+	Locale          *string
+	DateOfBirth     *graphql.Time
+	LicenceNumber   *string
+	LicenceIssuedAt *graphql.Time
+}) (*customerResolver, error) {
+	customer := model.Customer{Name: strings.TrimSpace(args.Name)}
+	if customer.Name == "" {
+		return nil, badInput("name is required")
+	}
+	if args.Email != nil {
+		customer.Email = strings.TrimSpace(*args.Email)
+	}
+	if args.Phone != nil {
+		customer.Phone = strings.TrimSpace(*args.Phone)
+	}
+	if args.Locale != nil {
+		customer.Locale = strings.ToLower(strings.TrimSpace(*args.Locale))
+	}
+	if args.DateOfBirth != nil {
+		customer.DateOfBirth = &args.DateOfBirth.Time
+	}
+	if args.LicenceNumber != nil {
+		customer.LicenceNumber = *args.LicenceNumber
+	}
+	if args.LicenceIssuedAt != nil {
+		customer.LicenceIssuedAt = &args.LicenceIssuedAt.Time
+	}
+
+	if err := r.service.CreateCustomer(ctx, &customer); err != nil {
+		return nil, r.fail(ctx, "Failed to create customer", err)
+	}
+
+	return r.newCustomers([]model.Customer{customer})[0], nil
+}
@@ -0,0 +1,85 @@
+package graphqlapi
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// maxPageSize bounds the first argument of the connections.
+const maxPageSize = 100
+
+// pageInfo resolves the PageInfo type.
+type pageInfo struct {
+	endCursor   *string
+	hasNextPage bool
+}
+
+func (p pageInfo) EndCursor() *string { return p.endCursor }
+func (p pageInfo) HasNextPage() bool  { return p.hasNextPage }
+
+// page holds the decoded pagination arguments of a connection.
+type page struct {
+	size    int
+	afterID uint
+}
+
+// parsePage validates the first and after arguments. Cursors are the opaque
+// id of the last node of the previous page.
+func parsePage(first int32, after *string) (page, error) {
+	p := page{size: int(first)}
+	if first < 1 || first > maxPageSize {
+		return p, badInput("first must be between 1 and " + strconv.Itoa(maxPageSize))
+	}
+	if after != nil && *after != "" {
+		id, err := decodeCursor(*after)
+		if err != nil {
+			return p, badInput("after is not a valid cursor")
+		}
+		p.afterID = id
+	}
+	return p, nil
+}
+
+// info builds the PageInfo of a page fetched with one extra node to tell
+// whether another page follows; it returns the number of nodes to keep.
+func (p page) info(fetched int, lastID func(i int) uint) (pageInfo, int) {
+	count := fetched
+	info := pageInfo{hasNextPage: fetched > p.size}
+	if info.hasNextPage {
+		count = p.size
+	}
+	if count > 0 {
+		cursor := encodeCursor(lastID(count - 1))
+		info.endCursor = &cursor
+	}
+	return info, count
+}
+
+func encodeCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	return uint(id), err
+}
+
+// parseID converts a GraphQL ID to a database id.
+func parseID(id graphql.ID) (uint, error) {
+	parsed, err := strconv.ParseUint(string(id), 10, 64)
+	if err != nil {
+		return 0, badInput("invalid id " + string(id))
+	}
+	return uint(parsed), nil
+}
+
+// toID converts a database id to a GraphQL ID.
+func toID(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
@@ -0,0 +1,215 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// carFilterInput is the CarFilter input type.
+type carFilterInput struct {
+	Make      *string
+	Model     *string
+	Category  *string
+	Branch    *string
+	Status    *string
+	Available *bool
+}
+
+// rentalFilterInput is the RentalFilter input type.
+type rentalFilterInput struct {
+	Registration *string
+	CustomerId   *graphql.ID
+	Open         *bool
+	NeedsReview  *bool
+}
+
+// connectionArgs are the pagination arguments of the connections.
+type connectionArgs struct {
+	First int32
+	After *string
+}
+
+// carConnection resolves the CarConnection type.
+type carConnection struct {
+	nodes []*carResolver
+	info  pageInfo
+}
+
+func (c *carConnection) Nodes() []*carResolver { return c.nodes }
+func (c *carConnection) PageInfo() pageInfo    { return c.info }
+
+// rentalConnection resolves the RentalConnection type.
+type rentalConnection struct {
+	nodes []*rentalResolver
+	info  pageInfo
+}
+
+func (c *rentalConnection) Nodes() []*rentalResolver { return c.nodes }
+func (c *rentalConnection) PageInfo() pageInfo       { return c.info }
+
+// customerConnection resolves the CustomerConnection type.
+type customerConnection struct {
+	nodes []*customerResolver
+	info  pageInfo
+}
+
+func (c *customerConnection) Nodes() []*customerResolver { return c.nodes }
+func (c *customerConnection) PageInfo() pageInfo         { return c.info }
+
+// Car returns the car with the given registration, or null.
+func (r *Resolver) Car(ctx context.Context, args struct{ Registration string }) (*carResolver, error) {
+	car, err := r.service.GetCar(ctx, args.Registration)
+	if errors.Is(err, service.ErrCarNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to get car", err)
+	}
+	return r.newCars([]model.Car{car})[0], nil
+}
+
+// Cars returns a page of the cars matching the filter.
+func (r *Resolver) Cars(ctx context.Context, args struct {
+	Filter *carFilterInput
+	connectionArgs
+}) (*carConnection, error) {
+	p, err := parsePage(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := service.CarFilter{AfterID: p.afterID, Limit: p.size + 1}
+	if f := args.Filter; f != nil {
+		if f.Make != nil {
+			filter.Make = *f.Make
+		}
+		if f.Model != nil {
+			filter.CarModel = *f.Model
+		}
+		if f.Category != nil {
+			pattern, err := model.NormalizeCategory(*f.Category, true)
+			if err != nil {
+				return nil, badInput("category must be a valid ACRISS code")
+			}
+			filter.Category = pattern
+		}
+		if f.Branch != nil {
+			filter.Branch = *f.Branch
+		}
+		if f.Status != nil {
+			filter.Status = model.CarStatus(*f.Status)
+		}
+		filter.Available = f.Available
+	}
+
+	cars, err := r.service.ListCars(ctx, filter)
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to list cars", err)
+	}
+
+	info, count := p.info(len(cars), func(i int) uint { return cars[i].ID })
+	return &carConnection{nodes: r.newCars(cars[:count]), info: info}, nil
+}
+
+// Rental returns the rental with the given id, or null.
+func (r *Resolver) Rental(ctx context.Context, args struct{ ID graphql.ID }) (*rentalResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rental, err := r.service.GetRental(ctx, id)
+	if errors.Is(err, service.ErrRentalNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to get rental", err)
+	}
+	return r.newRentals([]model.Rental{rental})[0], nil
+}
+
+// Rentals returns a page of the rentals matching the filter.
+func (r *Resolver) Rentals(ctx context.Context, args struct {
+	Filter *rentalFilterInput
+	connectionArgs
+}) (*rentalConnection, error) {
+	p, err := parsePage(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := service.RentalFilter{AfterID: p.afterID, Limit: p.size + 1}
+	if f := args.Filter; f != nil {
+		if f.Registration != nil {
+			car, err := r.service.GetCar(ctx, *f.Registration)
+			if errors.Is(err, service.ErrCarNotFound) {
+				return &rentalConnection{nodes: []*rentalResolver{}}, nil
+			}
+			if err != nil {
+				return nil, r.fail(ctx, "Failed to list rentals", err)
+			}
+			filter.CarID = car.ID
+		}
+		if f.CustomerId != nil {
+			if filter.CustomerID, err = parseID(*f.CustomerId); err != nil {
+				return nil, err
+			}
+		}
+		filter.Open = f.Open
+		filter.NeedsReview = f.NeedsReview
+	}
+
+	rentals, err := r.service.ListRentals(ctx, filter)
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to list rentals", err)
+	}
+
+	info, count := p.info(len(rentals), func(i int) uint { return rentals[i].ID })
+	return &rentalConnection{nodes: r.newRentals(rentals[:count]), info: info}, nil
+}
+
+// Customer returns the customer with the given id, or null.
+func (r *Resolver) Customer(ctx context.Context, args struct{ ID graphql.ID }) (*customerResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := r.service.GetCustomer(ctx, id)
+	if errors.Is(err, service.ErrCustomerNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to get customer", err)
+	}
+	return r.newCustomers([]model.Customer{customer})[0], nil
+}
+
+// Customers returns a page of the customers whose name or email contains
+// search.
+func (r *Resolver) Customers(ctx context.Context, args struct {
+	Search *string
+	connectionArgs
+}) (*customerConnection, error) {
+	p, err := parsePage(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := service.CustomerFilter{AfterID: p.afterID, Limit: p.size + 1}
+	if args.Search != nil {
+		filter.Search = *args.Search
+	}
+
+	customers, err := r.service.ListCustomers(ctx, filter)
+	if err != nil {
+		return nil, r.fail(ctx, "Failed to list customers", err)
+	}
+
+	info, count := p.info(len(customers), func(i int) uint { return customers[i].ID })
+	return &customerConnection{nodes: r.newCustomers(customers[:count]), info: info}, nil
+}
@@ -0,0 +1,314 @@
+package graphqlapi
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// lazy loads a value once, on first use, for every resolver sharing it.
+type lazy[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+func (l *lazy[T]) get(load func() (T, error)) (T, error) {
+	l.once.Do(func() { l.value, l.err = load() })
+	return l.value, l.err
+}
+
+// optionalTime converts an optional time to the GraphQL scalar.
+func optionalTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+// carBatch holds the cars resolved by one field and loads their rentals for
+// all of them in a single query the first time one is needed, and again for
+// every other number of rentals asked for.
+type carBatch struct {
+	resolver *Resolver
+	ids      []uint
+	mu       sync.Mutex
+	rentals  map[int]*lazy[map[uint][]model.Rental]
+}
+
+// newCars wraps cars in resolvers sharing one batch.
+func (r *Resolver) newCars(cars []model.Car) []*carResolver {
+	batch := &carBatch{resolver: r}
+	resolvers := make([]*carResolver, len(cars))
+	for i, car := range cars {
+		batch.ids = append(batch.ids, car.ID)
+		resolvers[i] = &carResolver{car: car, batch: batch}
+	}
+	return resolvers
+}
+
+// loadRentals loads the latest first rentals of every car of the batch.
+func (b *carBatch) loadRentals(ctx context.Context, first int) (map[uint][]model.Rental, error) {
+	b.mu.Lock()
+	if b.rentals == nil {
+		b.rentals = map[int]*lazy[map[uint][]model.Rental]{}
+	}
+	latest, ok := b.rentals[first]
+	if !ok {
+		latest = &lazy[map[uint][]model.Rental]{}
+		b.rentals[first] = latest
+	}
+	b.mu.Unlock()
+
+	return latest.get(func() (map[uint][]model.Rental, error) {
+		rentals, err := b.resolver.service.RentalsByCar(ctx, b.ids, first)
+		if err != nil {
+			return nil, b.resolver.fail(ctx, "Failed to load rentals", err)
+		}
+		return rentals, nil
+	})
+}
+
+// carResolver resolves the Car type.
+type carResolver struct {
+	car   model.Car
+	batch *carBatch
+}
+
+func (c *carResolver) ID() graphql.ID                 { return toID(c.car.ID) }
+func (c *carResolver) Registration() string           { return c.car.Registration }
+func (c *carResolver) Model() string                  { return c.car.CarModel }
+func (c *carResolver) Make() string                   { return c.car.Make }
+func (c *carResolver) Year() int32                    { return int32(c.car.Year) }
+func (c *carResolver) Vin() string                    { return c.car.VIN }
+func (c *carResolver) Colour() string                 { return c.car.Colour }
+func (c *carResolver) Seats() int32                   { return int32(c.car.Seats) }
+func (c *carResolver) Transmission() string           { return string(c.car.Transmission) }
+func (c *carResolver) FuelType() string               { return string(c.car.FuelType) }
+func (c *carResolver) Category() string               { return c.car.Category }
+func (c *carResolver) Branch() string                 { return c.car.Branch }
+func (c *carResolver) Mileage() float64               { return c.car.Mileage }
+func (c *carResolver) NextServiceMileage() float64    { return c.car.NextServiceMileage }
+func (c *carResolver) DailyRate() float64             { return c.car.DailyRate }
+func (c *carResolver) Status() string                 { return string(c.car.Status) }
+func (c *carResolver) Available() bool                { return c.car.Available }
+func (c *carResolver) StatusChangedAt() *graphql.Time { return optionalTime(c.car.StatusChangedAt) }
+func (c *carResolver) LastRentedAt() *graphql.Time    { return optionalTime(c.car.LastRentedAt) }
+func (c *carResolver) Version() int32                 { return int32(c.car.Version) }
+
+// CurrentRental returns the rental in progress, if the car is rented: it is
+// always the latest rental of the car.
+func (c *carResolver) CurrentRental(ctx context.Context) (*rentalResolver, error) {
+	rentals, err := c.batch.loadRentals(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, rental := range rentals[c.car.ID] {
+		if rental.EndedAt == nil {
+			return c.batch.resolver.newRentals([]model.Rental{rental})[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// Rentals returns the latest rentals of the car, most recent first.
+func (c *carResolver) Rentals(ctx context.Context, args struct{ First int32 }) ([]*rentalResolver, error) {
+	if args.First < 0 || args.First > maxPageSize {
+		return nil, badInput("first must be between 0 and " + strconv.Itoa(maxPageSize))
+	}
+	rentals, err := c.batch.loadRentals(ctx, int(args.First))
+	if err != nil {
+		return nil, err
+	}
+	return c.batch.resolver.newRentals(rentals[c.car.ID]), nil
+}
+
+// rentalBatch holds the rentals resolved by one field and loads their cars
+// and customers for all of them in a single query each.
+type rentalBatch struct {
+	resolver  *Resolver
+	rentals   []model.Rental
+	cars      lazy[map[uint]*carResolver]
+	customers lazy[map[uint]*customerResolver]
+}
+
+// newRentals wraps rentals in resolvers sharing one batch.
+func (r *Resolver) newRentals(rentals []model.Rental) []*rentalResolver {
+	batch := &rentalBatch{resolver: r, rentals: rentals}
+	resolvers := make([]*rentalResolver, len(rentals))
+	for i, rental := range rentals {
+		resolvers[i] = &rentalResolver{rental: rental, batch: batch}
+	}
+	return resolvers
+}
+
+func (b *rentalBatch) loadCars(ctx context.Context) (map[uint]*carResolver, error) {
+	return b.cars.get(func() (map[uint]*carResolver, error) {
+		ids := make([]uint, 0, len(b.rentals))
+		for _, rental := range b.rentals {
+			ids = append(ids, rental.CarID)
+		}
+		cars, err := b.resolver.service.CarsByID(ctx, ids)
+		if err != nil {
+			return nil, b.resolver.fail(ctx, "Failed to load cars", err)
+		}
+
+		// The cars share a batch so that their own rentals load together.
+		list := make([]model.Car, 0, len(cars))
+		for _, car := range cars {
+			list = append(list, car)
+		}
+		resolvers := make(map[uint]*carResolver, len(list))
+		for _, resolver := range b.resolver.newCars(list) {
+			resolvers[resolver.car.ID] = resolver
+		}
+		return resolvers, nil
+	})
+}
+
+func (b *rentalBatch) loadCustomers(ctx context.Context) (map[uint]*customerResolver, error) {
+	return b.customers.get(func() (map[uint]*customerResolver, error) {
+		var ids []uint
+		for _, rental := range b.rentals {
+			if rental.CustomerID != nil {
+				ids = append(ids, *rental.CustomerID)
+			}
+		}
+		customers, err := b.resolver.service.CustomersByID(ctx, ids)
+		if err != nil {
+			return nil, b.resolver.fail(ctx, "Failed to load customers", err)
+		}
+
+		list := make([]model.Customer, 0, len(customers))
+		for _, customer := range customers {
+			list = append(list, customer)
+		}
+		resolvers := make(map[uint]*customerResolver, len(list))
+		for _, resolver := range b.resolver.newCustomers(list) {
+			resolvers[resolver.customer.ID] = resolver
+		}
+		return resolvers, nil
+	})
+}
+
+// rentalResolver resolves the Rental type.
+type rentalResolver struct {
+	rental model.Rental
+	batch  *rentalBatch
+}
+
+func (r *rentalResolver) ID() graphql.ID                { return toID(r.rental.ID) }
+func (r *rentalResolver) StartedAt() graphql.Time       { return graphql.Time{Time: r.rental.StartedAt} }
+func (r *rentalResolver) EndedAt() *graphql.Time        { return optionalTime(r.rental.EndedAt) }
+func (r *rentalResolver) PlannedEndAt() *graphql.Time   { return optionalTime(r.rental.PlannedEndAt) }
+func (r *rentalResolver) StartOdometer() float64        { return r.rental.StartOdometer }
+func (r *rentalResolver) EndOdometer() *float64         { return r.rental.EndOdometer }
+func (r *rentalResolver) Distance() float64             { return r.rental.Distance }
+func (r *rentalResolver) DailyRate() float64            { return r.rental.DailyRate }
+func (r *rentalResolver) YoungDriverSurcharge() float64 { return r.rental.YoungDriverSurcharge }
+func (r *rentalResolver) Deposit() float64              { return r.rental.Deposit }
+func (r *rentalResolver) PlannedAmount() float64        { return r.rental.PlannedAmount }
+func (r *rentalResolver) Amount() float64               { return r.rental.Amount }
+func (r *rentalResolver) Adjustment() float64           { return r.rental.Adjustment }
+func (r *rentalResolver) Overdue() bool                 { return r.rental.Overdue }
+func (r *rentalResolver) LateFee() float64              { return r.rental.LateFee }
+func (r *rentalResolver) NeedsReview() bool             { return r.rental.NeedsReview }
+
+// Car returns the rented car.
+func (r *rentalResolver) Car(ctx context.Context) (*carResolver, error) {
+	cars, err := r.batch.loadCars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	car, ok := cars[r.rental.CarID]
+	if !ok {
+		return nil, &resolverError{message: "car of rental not found", code: "NOT_FOUND"}
+	}
+	return car, nil
+}
+
+// Customer returns the customer renting the car, if known.
+func (r *rentalResolver) Customer(ctx context.Context) (*customerResolver, error) {
+	if r.rental.CustomerID == nil {
+		return nil, nil
+	}
+	customers, err := r.batch.loadCustomers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return customers[*r.rental.CustomerID], nil
+}
+
+// customerBatch holds the customers resolved by one field and loads their
+// rentals for all of them in a single query.
+type customerBatch struct {
+	resolver *Resolver
+	ids      []uint
+	rentals  lazy[map[uint][]*rentalResolver]
+}
+
+// newCustomers wraps customers in resolvers sharing one batch.
+func (r *Resolver) newCustomers(customers []model.Customer) []*customerResolver {
+	batch := &customerBatch{resolver: r}
+	resolvers := make([]*customerResolver, len(customers))
+	for i, customer := range customers {
+		batch.ids = append(batch.ids, customer.ID)
+		resolvers[i] = &customerResolver{customer: customer, batch: batch}
+	}
+	return resolvers
+}
+
+func (b *customerBatch) loadRentals(ctx context.Context) (map[uint][]*rentalResolver, error) {
+	return b.rentals.get(func() (map[uint][]*rentalResolver, error) {
+		rentals, err := b.resolver.service.RentalsByCustomer(ctx, b.ids)
+		if err != nil {
+			return nil, b.resolver.fail(ctx, "Failed to load rentals", err)
+		}
+
+		// All the rentals share a batch so that their cars load together.
+		var all []model.Rental
+		for _, id := range b.ids {
+			all = append(all, rentals[id]...)
+		}
+		resolvers := make(map[uint][]*rentalResolver, len(b.ids))
+		for _, resolver := range b.resolver.newRentals(all) {
+			id := *resolver.rental.CustomerID
+			resolvers[id] = append(resolvers[id], resolver)
+		}
+		return resolvers, nil
+	})
+}
+
+// customerResolver resolves the Customer type.
+type customerResolver struct {
+	customer model.Customer
+	batch    *customerBatch
+}
+
+func (c *customerResolver) ID() graphql.ID             { return toID(c.customer.ID) }
+func (c *customerResolver) Name() string               { return c.customer.Name }
+func (c *customerResolver) Email() string              { return c.customer.Email }
+func (c *customerResolver) Phone() string              { return c.customer.Phone }
+func (c *customerResolver) Locale() string             { return c.customer.Locale }
+func (c *customerResolver) DateOfBirth() *graphql.Time { return optionalTime(c.customer.DateOfBirth) }
+func (c *customerResolver) LicenceNumber() string      { return c.customer.LicenceNumber }
+func (c *customerResolver) LicenceIssuedAt() *graphql.Time {
+	return optionalTime(c.customer.LicenceIssuedAt)
+}
+func (c *customerResolver) CreatedAt() graphql.Time { return graphql.Time{Time: c.customer.CreatedAt} }
+
+// Rentals returns the rentals of the customer, most recent first.
+func (c *customerResolver) Rentals(ctx context.Context) ([]*rentalResolver, error) {
+	rentals, err := c.batch.loadRentals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rentals[c.customer.ID] == nil {
+		return []*rentalResolver{}, nil
+	}
+	return rentals[c.customer.ID], nil
+}
@@ -0,0 +1,103 @@
+package grpcapi
+
+import (
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/fleetpb"
+	"github.com/abdeel07/backend-go-cars/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp converts an optional time to its protobuf form.
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// toCar converts a car of the model to its protobuf form.
+func toCar(car model.Car) *fleetpb.Car {
+	return &fleetpb.Car{
+		Id:                 uint64(car.ID),
+		Model:              car.CarModel,
+		Make:               car.Make,
+		Year:               int32(car.Year),
+		Vin:                car.VIN,
+		Colour:             car.Colour,
+		Seats:              int32(car.Seats),
+		Transmission:       string(car.Transmission),
+		FuelType:           string(car.FuelType),
+		Registration:       car.Registration,
+		Category:           car.Category,
+		Branch:             car.Branch,
+		Mileage:            car.Mileage,
+		NextServiceMileage: car.NextServiceMileage,
+		DailyRate:          car.DailyRate,
+		PurchasePrice:      car.PurchasePrice,
+		PurchaseDate:       timestamp(car.PurchaseDate),
+		Status:             string(car.Status),
+		Available:          car.Available,
+		StatusChangedAt:    timestamp(car.StatusChangedAt),
+		LastRentedAt:       timestamp(car.LastRentedAt),
+		CreatedAt:          timestamppb.New(car.CreatedAt),
+		UpdatedAt:          timestamppb.New(car.UpdatedAt),
+		Version:            uint64(car.Version),
+	}
+}
+
+// fromCar converts the writable fields of a protobuf car to the model.
+func fromCar(car *fleetpb.Car) model.Car {
+	result := model.Car{
+		CarModel:           car.GetModel(),
+		Make:               car.GetMake(),
+		Year:               int(car.GetYear()),
+		VIN:                car.GetVin(),
+		Colour:             car.GetColour(),
+		Seats:              int(car.GetSeats()),
+		Transmission:       model.Transmission(car.GetTransmission()),
+		FuelType:           model.FuelType(car.GetFuelType()),
+		Registration:       car.GetRegistration(),
+		Category:           car.GetCategory(),
+		Branch:             car.GetBranch(),
+		Mileage:            car.GetMileage(),
+		NextServiceMileage: car.GetNextServiceMileage(),
+		DailyRate:          car.GetDailyRate(),
+		PurchasePrice:      car.GetPurchasePrice(),
+	}
+	if car.GetPurchaseDate() != nil {
+		purchaseDate := car.GetPurchaseDate().AsTime()
+		result.PurchaseDate = &purchaseDate
+	}
+	return result
+}
+
+// toRental converts a rental of the model to its protobuf form.
+func toRental(rental *model.Rental) *fleetpb.Rental {
+	if rental == nil {
+		return nil
+	}
+	return &fleetpb.Rental{
+		Id:            uint64(rental.ID),
+		CarId:         uint64(rental.CarID),
+		StartedAt:     timestamppb.New(rental.StartedAt),
+		EndedAt:       timestamp(rental.EndedAt),
+		StartOdometer: rental.StartOdometer,
+		EndOdometer:   rental.EndOdometer,
+		Distance:      rental.Distance,
+		DailyRate:     rental.DailyRate,
+		Amount:        rental.Amount,
+		NeedsReview:   rental.NeedsReview,
+		ReviewReason:  rental.ReviewReason,
+		CustomerId:    customerID(rental.CustomerID),
+	}
+}
+
+// customerID converts an optional customer id to its protobuf form.
+func customerID(id *uint) *uint64 {
+	if id == nil {
+		return nil
+	}
+	value := uint64(*id)
+	return &value
+}
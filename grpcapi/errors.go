@@ -0,0 +1,52 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeOf maps the domain errors of the service layer to gRPC codes, the same
+// way the REST handlers map them to HTTP statuses.
+func codeOf(err error) codes.Code {
+	switch {
+	case errors.Is(err, service.ErrCarNotFound), errors.Is(err, service.ErrRentalNotFound),
+		errors.Is(err, service.ErrCustomerNotFound):
+		return codes.NotFound
+	case errors.Is(err, service.ErrCarExists), errors.Is(err, service.ErrVINExists):
+		return codes.AlreadyExists
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, service.ErrNoCarAvailable),
+		errors.Is(err, eligibility.ErrNotEligible), errors.Is(err, payment.ErrDeclined),
+		errors.Is(err, service.ErrPaymentMethodRequired), errors.Is(err, service.ErrReservationConflict),
+		errors.Is(err, service.ErrRentalClosed):
+		return codes.FailedPrecondition
+	case errors.Is(err, service.ErrVersionMismatch):
+		return codes.Aborted
+	case errors.Is(err, service.ErrOdometerRollback), errors.Is(err, service.ErrUnknownStrategy),
+		errors.Is(err, model.ErrInvalidCategory), errors.Is(err, service.ErrInvalidPeriod):
+		return codes.InvalidArgument
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus converts a service error to a gRPC status error. Internal errors
+// are logged and their details hidden from the client.
+func (f *FleetServer) toStatus(ctx context.Context, message string, err error) error {
+	code := codeOf(err)
+	if code == codes.Internal {
+		f.Logger.ErrorContext(ctx, message, "error", err)
+		return status.Error(code, message)
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,220 @@
+// Package grpcapi serves the FleetService gRPC API on top of the same service
+// layer as the REST handlers.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/abdeel07/backend-go-cars/events"
+	"github.com/abdeel07/backend-go-cars/fleetpb"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FleetServer implements fleetpb.FleetServiceServer.
+type FleetServer struct {
+	fleetpb.UnimplementedFleetServiceServer
+
+	Service *service.ParkingLotService
+	Events  *events.Broker
+	Logger  *slog.Logger
+}
+
+// NewFleetServer creates a FleetServer sharing the service and event broker
+// of the REST server.
+func NewFleetServer(s *server.Server) *FleetServer {
+	return &FleetServer{
+		Service: s.ParkingLotService,
+		Events:  s.Events,
+		Logger:  s.Logger,
+	}
+}
+
+// NewGRPCServer creates a gRPC server with the FleetService registered. Calls
+// are scoped to the tenant resolved from their metadata.
+func NewGRPCServer(s *server.Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryTenant(s.Tenants)),
+		grpc.ChainStreamInterceptor(streamTenant(s.Tenants)),
+	}, opts...)
+	grpcServer := grpc.NewServer(opts...)
+	fleetpb.RegisterFleetServiceServer(grpcServer, NewFleetServer(s))
+	return grpcServer
+}
+
+// AddCar adds a car to the fleet.
+func (f *FleetServer) AddCar(ctx context.Context, request *fleetpb.AddCarRequest) (*fleetpb.Car, error) {
+	if request.GetCar() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Car is required")
+	}
+
+	car := fromCar(request.GetCar())
+	if message := model.ValidateCar(&car); message != "" {
+		return nil, status.Error(codes.InvalidArgument, message)
+	}
+
+	if err := f.Service.AddCar(ctx, &car); err != nil {
+		return nil, f.toStatus(ctx, "Failed to create car", err)
+	}
+
+	return toCar(car), nil
+}
+
+// GetCar returns a car by registration.
+func (f *FleetServer) GetCar(ctx context.Context, request *fleetpb.GetCarRequest) (*fleetpb.Car, error) {
+	car, err := f.Service.GetCar(ctx, request.GetRegistration())
+	if err != nil {
+		return nil, f.toStatus(ctx, "Failed to get car", err)
+	}
+
+	return toCar(car), nil
+}
+
+// ListCars streams the cars matching the filter.
+func (f *FleetServer) ListCars(request *fleetpb.ListCarsRequest, stream fleetpb.FleetService_ListCarsServer) error {
+	ctx := stream.Context()
+
+	filter := service.CarFilter{
+		Make:     request.GetMake(),
+		CarModel: request.GetModel(),
+		Branch:   request.GetBranch(),
+		Status:   model.CarStatus(request.GetStatus()),
+	}
+	if request.GetCategory() != "" {
+		pattern, err := model.NormalizeCategory(request.GetCategory(), true)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "Category must be a valid ACRISS code")
+		}
+		filter.Category = pattern
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return status.Error(codes.InvalidArgument, "Status must be a known car status")
+	}
+	if request.Available != nil {
+		available := request.GetAvailable()
+		filter.Available = &available
+	}
+
+	cars, err := f.Service.ListCars(ctx, filter)
+	if err != nil {
+		return f.toStatus(ctx, "Failed to list cars", err)
+	}
+
+	for _, car := range cars {
+		if err := stream.Send(toCar(car)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RentCar rents a car by registration, or any car of a category.
+func (f *FleetServer) RentCar(ctx context.Context, request *fleetpb.RentCarRequest) (*fleetpb.RentalResponse, error) {
+	var car model.Car
+	var rental model.Rental
+	var err error
+
+	var opts service.RentOptions
+	if request.CustomerId != nil {
+		customerID := uint(request.GetCustomerId())
+		opts.CustomerID = &customerID
+	}
+
+	if request.GetRegistration() != "" {
+		car, rental, err = f.Service.RentCar(ctx, request.GetRegistration(), opts)
+	} else {
+		pattern, categoryErr := model.NormalizeCategory(request.GetCategory(), true)
+		if categoryErr != nil {
+			return nil, status.Error(codes.InvalidArgument, "Registration or a valid ACRISS category is required")
+		}
+		strategy, strategyErr := service.ParseAllocationStrategy(request.GetStrategy())
+		if strategyErr != nil {
+			return nil, status.Error(codes.InvalidArgument, "Strategy must be one of lowest_mileage, round_robin or service_due")
+		}
+		car, rental, err = f.Service.RentByCategory(ctx, pattern, strategy, opts)
+	}
+	if err != nil {
+		return nil, f.toStatus(ctx, "Failed to rent car", err)
+	}
+
+	return &fleetpb.RentalResponse{Car: toCar(car), Rental: toRental(&rental)}, nil
+}
+
+// ReturnCar returns a rented car with its odometer reading.
+func (f *FleetServer) ReturnCar(ctx context.Context, request *fleetpb.ReturnCarRequest) (*fleetpb.RentalResponse, error) {
+	if request.GetOdometer() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Odometer reading must be positive")
+	}
+
+	car, rental, err := f.Service.ReturnCar(ctx, request.GetRegistration(), request.GetOdometer())
+	if err != nil {
+		return nil, f.toStatus(ctx, "Failed to return car", err)
+	}
+
+	return &fleetpb.RentalResponse{Car: toCar(car), Rental: toRental(&rental)}, nil
+}
+
+// DeleteCar retires and deletes a car.
+func (f *FleetServer) DeleteCar(ctx context.Context, request *fleetpb.DeleteCarRequest) (*fleetpb.DeleteCarResponse, error) {
+	if err := f.Service.DeleteCar(ctx, request.GetRegistration()); err != nil {
+		return nil, f.toStatus(ctx, "Failed to delete car", err)
+	}
+
+	return &fleetpb.DeleteCarResponse{}, nil
+}
+
+// WatchCars streams the changes of the fleet, replaying the buffered events
+// after after_event_id when it is set.
+func (f *FleetServer) WatchCars(request *fleetpb.WatchCarsRequest, stream fleetpb.FleetService_WatchCarsServer) error {
+	tenantID, _ := tenant.FromContext(stream.Context())
+	filter := events.Filter{Tenant: tenantID, Registration: request.GetRegistration(), Branch: request.GetBranch()}
+	subscription, replay, complete := f.Events.Subscribe(filter, uint(request.GetAfterEventId()), request.AfterEventId != nil)
+	defer f.Events.Unsubscribe(subscription)
+
+	if !complete {
+		if err := stream.Send(&fleetpb.CarEvent{Type: "reset"}); err != nil {
+			return err
+		}
+	}
+	for _, event := range replay {
+		if err := stream.Send(toCarEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-subscription.C:
+			if !ok {
+				// The watcher fell behind or the server is stopping.
+				return status.Error(codes.Unavailable, "Event stream closed, resume with after_event_id")
+			}
+			if err := stream.Send(toCarEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toCarEvent converts a broker event to its protobuf form.
+func toCarEvent(event events.Event) *fleetpb.CarEvent {
+	var payload service.FleetEvent
+	json.Unmarshal(event.Data, &payload)
+
+	return &fleetpb.CarEvent{
+		Id:     uint64(event.ID),
+		Type:   event.Type,
+		Car:    toCar(payload.Car),
+		Rental: toRental(payload.Rental),
+	}
+}
@@ -0,0 +1,71 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenantMetadata is the metadata key of the tenant, the gRPC counterpart of
+// the X-Tenant-ID header.
+const tenantMetadata = "x-tenant-id"
+
+// withTenant resolves the tenant of a call from its authorization and
+// x-tenant-id metadata, as the REST middleware does from the headers.
+func withTenant(ctx context.Context, resolver *tenant.Resolver) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	id, err := resolver.Resolve(first("authorization"), first(tenantMetadata))
+	switch {
+	case errors.Is(err, tenant.ErrInvalidID):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, tenant.ErrMismatch), errors.Is(err, tenant.ErrHeader):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return tenant.WithID(ctx, id), nil
+}
+
+// tenantStream overrides the context of a server stream.
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
+
+// unaryTenant scopes unary calls to their tenant.
+func unaryTenant(resolver *tenant.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := withTenant(ctx, resolver)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// streamTenant scopes streaming calls to their tenant.
+func streamTenant(resolver *tenant.Resolver) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withTenant(stream.Context(), resolver)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: stream, ctx: ctx})
+	}
+}
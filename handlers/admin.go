@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/server"
+)
+
+// AdminTokenHeader carries the admin token of the deployment-wide routes.
+const AdminTokenHeader = "X-Admin-Token"
+
+// RequireAdmin serves the request with next only when it carries the admin
+// token. Tenants, whatever their credentials, cannot use these routes.
+func RequireAdmin(s *server.Server, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.AdminToken == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{"Admin routes are disabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(AdminTokenHeader)), []byte(s.AdminToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{"A valid " + AdminTokenHeader + " header is required"})
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// EligibilityResponse lists the rules failed by the drivers of a refused rental.
+type EligibilityResponse struct {
+	Error    string                `json:"error"`
+	Failures []eligibility.Failure `json:"failures"`
+}
+
+// notEligible writes the failures of a rental refused by the eligibility
+// rules, returning false if err is not such a refusal.
+func notEligible(w http.ResponseWriter, err error) bool {
+	var refused *eligibility.Error
+	if !errors.As(err, &refused) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(EligibilityResponse{"Drivers are not eligible for this rental", refused.Failures})
+	return true
+}
+
+// BlockDriver handles the POST HTTP request to add a licence number or an
+// email to the blocklist.
+func BlockDriver(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var entry model.BlockedDriver
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid blocklist payload"})
+			return
+		}
+
+		if model.NormalizeLicenceNumber(entry.LicenceNumber) == "" && strings.TrimSpace(entry.Email) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"A licence number or an email is required"})
+			return
+		}
+
+		if err := s.ParkingLotService.BlockDriver(r.Context(), &entry); err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to update blocklist", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to update blocklist"})
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+// ListBlockedDrivers handles the GET HTTP request to list the blocklist.
+func ListBlockedDrivers(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		entries, err := s.ParkingLotService.ListBlockedDrivers(r.Context())
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list blocklist", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list blocklist"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(entries)
+	}
+}
+
+// UnblockDriver handles the DELETE HTTP request to remove an entry from the
+// blocklist.
+func UnblockDriver(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid blocklist id"})
+			return
+		}
+
+		err := s.ParkingLotService.UnblockDriver(r.Context(), id)
+		if errors.Is(err, service.ErrBlockedDriverNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Blocklist entry not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to update blocklist", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to update blocklist"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
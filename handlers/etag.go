@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// carETag is the strong entity tag of a car, derived from its version.
+func carETag(car model.Car) string {
+	return `"` + strconv.FormatUint(uint64(car.Version), 10) + `"`
+}
+
+// listETag is a weak entity tag over the identity and version of every car
+// in a list, so that any addition, removal or change produces a new tag.
+func listETag(cars []model.Car) string {
+	hash := sha256.New()
+	buf := make([]byte, 16)
+	for _, car := range cars {
+		binary.BigEndian.PutUint64(buf[:8], uint64(car.ID))
+		binary.BigEndian.PutUint64(buf[8:], uint64(car.Version))
+		hash.Write(buf)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// splitETags splits a comma separated list of entity tags.
+func splitETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified sets the ETag header and reports whether the If-None-Match
+// header of the request matches it, using the weak comparison.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range splitETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// withIfMatch applies the If-Match header of the request to the context
+// passed to the service, so that the change fails with
+// service.ErrVersionMismatch unless the car is still at a listed version.
+// Weak and malformed tags never match, as required for If-Match.
+func withIfMatch(r *http.Request) *http.Request {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return r
+	}
+
+	versions := []uint{}
+	for _, tag := range splitETags(header) {
+		if tag == "*" {
+			return r
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 0)
+		if err == nil {
+			versions = append(versions, uint(version))
+		}
+	}
+	return r.WithContext(service.WithIfMatch(r.Context(), versions))
+}
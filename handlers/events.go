@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/events"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/tenant"
+)
+
+// writeEvent writes an event in the Server-Sent Events format.
+func writeEvent(w io.Writer, event events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// StreamCarEvents handles the GET HTTP request to stream fleet changes as
+// Server-Sent Events, optionally filtered by registration or branch.
+func StreamCarEvents(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		controller := http.NewResponseController(w)
+
+		// Extract the filter parameters from the request. Clients only see
+		// the events of their tenant.
+		tenantID, _ := tenant.FromContext(r.Context())
+		filter := events.Filter{
+			Tenant:       tenantID,
+			Registration: r.URL.Query().Get("registration"),
+			Branch:       r.URL.Query().Get("branch"),
+		}
+
+		// A reconnecting client sends the id of the last event it received.
+		var lastID uint64
+		resume := false
+		if header := r.Header.Get("Last-Event-ID"); header != "" {
+			var err error
+			lastID, err = strconv.ParseUint(header, 10, 64)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{"Last-Event-ID must be an event id"})
+				return
+			}
+			resume = true
+		}
+
+		subscription, replay, complete := s.Events.Subscribe(filter, uint(lastID), resume)
+		defer s.Events.Unsubscribe(subscription)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		// Events were lost since the client's last one: it must reload the cars.
+		if !complete {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, event := range replay {
+			writeEvent(w, event)
+		}
+		if err := controller.Flush(); err != nil {
+			s.Logger.ErrorContext(r.Context(), "Event stream cannot be flushed", "error", err)
+			return
+		}
+
+		heartbeat := time.NewTicker(s.Events.Heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-subscription.C:
+				if !ok {
+					return
+				}
+				writeEvent(w, event)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -2,26 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/abdeel07/backend-go-cars/model"
 	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
 	"github.com/gorilla/mux"
 )
 
 // CarResponse represents the response structure for car-related operations.
 type CarResponse struct {
-	Message string    `json:"message"`
-	Car     model.Car `json:"car"`
+	Message string        `json:"message"`
+	Car     model.Car     `json:"car"`
+	Rental  *model.Rental `json:"rental,omitempty"`
 }
 
-// ListCars handles the GET HTTP request to list all cars.
+// ListCars handles the GET HTTP request to list cars, optionally filtered by
+// make, model, year range, VIN, colour, seats, transmission, fuel type,
+// category and availability.
 func ListCars(s *server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
-		var cars []model.Car
-		s.ParkingLotService.DB.Find(&cars)
+		// Build the search criteria from the query string.
+		filter, message := parseCarFilter(r.URL.Query())
+		if message != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{message})
+			return
+		}
+
+		cars, err := s.ParkingLotService.ListCars(r.Context(), filter)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list cars", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list cars"})
+			return
+		}
+
+		if notModified(w, r, listETag(cars)) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(cars)
@@ -42,37 +67,32 @@ func AddCar(s *server.Server) http.HandlerFunc {
 		}
 
 		// Validate the request parameters.
-		if car.Mileage < 0 {
+		if message := model.ValidateCar(&car); message != "" {
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{"Mileage parameter must be positive"})
+			json.NewEncoder(w).Encode(ErrorResponse{message})
 			return
-
 		}
-		if car.CarModel == "" || car.Registration == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{"Car model and registration are required"})
-			return
-		}
-
-		// Check if the car already exists in the system.
-		exists, _ := s.ParkingLotService.IsExist(car.Registration)
 
-		if exists {
-			// Return a not found response if the car is not found.
+		// Create the car in the database, rejecting duplicates.
+		err := s.ParkingLotService.AddCar(r.Context(), &car)
+		if errors.Is(err, service.ErrCarExists) {
 			w.WriteHeader(http.StatusConflict)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car already exists"})
 			return
-
 		}
-
-		// Set car availability and create the car in the database.
-		car.Available = true
-		if err := s.ParkingLotService.DB.Create(&car).Error; err != nil {
+		if errors.Is(err, service.ErrVINExists) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"A car with this VIN already exists"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to create car", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			json.NewEncoder(w).Encode(ErrorResponse{"Failed to create car"})
 			return
 		}
 
+		w.Header().Set("ETag", carETag(car))
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(car)
 	}
@@ -87,39 +107,153 @@ func RentCar(s *server.Server) http.HandlerFunc {
 		params := mux.Vars(r)
 		registration := params["registration"]
 
-		// Check if the specified car exists in the system.
-		exists, car := s.ParkingLotService.IsExist(registration)
+		// The body is optional and may name the customer renting the car, the
+		// additional drivers, the payment method holding the deposit and when
+		// the car is expected back.
+		var payload struct {
+			CustomerID          *uint      `json:"customer_id"`
+			AdditionalDriverIDs []uint     `json:"additional_driver_ids"`
+			PaymentMethod       string     `json:"payment_method"`
+			PlannedEndAt        *time.Time `json:"planned_end_at"`
+		}
+		if r.Body != nil {
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && err != io.EOF {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental payload"})
+				return
+			}
+		}
 
-		if !exists {
+		// Mark the car as rented if it exists and is available.
+		car, rental, err := s.ParkingLotService.RentCar(withIfMatch(r).Context(), registration, service.RentOptions{
+			CustomerID:          payload.CustomerID,
+			AdditionalDriverIDs: payload.AdditionalDriverIDs,
+			PaymentMethod:       payload.PaymentMethod,
+			PlannedEndAt:        payload.PlannedEndAt,
+		})
+		if errors.Is(err, service.ErrCarNotFound) {
 			// Return a not found response if the car is not found.
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
 			return
 		}
-
-		// Check if the car is available for rent.
-		if !car.Available {
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Customer not found"})
+			return
+		}
+		if notEligible(w, err) || depositRefused(w, err) || reservationRefused(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransition) {
 			w.WriteHeader(http.StatusConflict)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car is not available"})
 			return
 		}
-
-		// Set car availability to false and save the changes in the database.
-		car.Available = false
-		s.ParkingLotService.DB.Save(&car)
+		if errors.Is(err, service.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car was modified since it was read"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to rent car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to rent car"})
+			return
+		}
 
 		// Create a response indicating the successful rental of the car.
 		response := CarResponse{
-			Message: "The Car with registration " + registration + " is rented!",
+			Message: "The Car with registration " + car.Registration + " is rented!",
+			Car:     car,
+			Rental:  &rental,
+		}
+
+		w.Header().Set("ETag", carETag(car))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// RentByCategory handles the POST HTTP request to rent any available car of a
+// category, letting the allocation strategy choose which one.
+func RentByCategory(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Define a structure to hold the requested category and strategy.
+		type CategoryPayload struct {
+			Category            string     `json:"category"`
+			Strategy            string     `json:"strategy"`
+			CustomerID          *uint      `json:"customer_id"`
+			AdditionalDriverIDs []uint     `json:"additional_driver_ids"`
+			PaymentMethod       string     `json:"payment_method"`
+			PlannedEndAt        *time.Time `json:"planned_end_at"`
+		}
+
+		var payload CategoryPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental payload"})
+			return
+		}
+
+		// Validate the category pattern; '*' matches any letter at its position.
+		pattern, err := model.NormalizeCategory(payload.Category, true)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Category must be a valid ACRISS code"})
+			return
+		}
+
+		strategy, err := service.ParseAllocationStrategy(payload.Strategy)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Strategy must be one of lowest_mileage, round_robin or service_due"})
+			return
+		}
+
+		car, rental, err := s.ParkingLotService.RentByCategory(r.Context(), pattern, strategy, service.RentOptions{
+			CustomerID:          payload.CustomerID,
+			AdditionalDriverIDs: payload.AdditionalDriverIDs,
+			PaymentMethod:       payload.PaymentMethod,
+			PlannedEndAt:        payload.PlannedEndAt,
+		})
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Customer not found"})
+			return
+		}
+		if notEligible(w, err) || depositRefused(w, err) || reservationRefused(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrNoCarAvailable) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"No car available in category " + pattern})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to rent car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to rent car"})
+			return
+		}
+
+		// Create a response indicating which car was rented.
+		response := CarResponse{
+			Message: "The Car with registration " + car.Registration + " is rented!",
 			Car:     car,
+			Rental:  &rental,
 		}
 
+		w.Header().Set("ETag", carETag(car))
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
 	}
 }
 
-// ReturnCar handles the PUT HTTP request to return a rented car.
+// ReturnCar handles the PUT HTTP request to return a rented car with the
+// absolute odometer reading shown on the dashboard.
 func ReturnCar(s *server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -128,55 +262,65 @@ func ReturnCar(s *server.Server) http.HandlerFunc {
 		params := mux.Vars(r)
 		registration := params["registration"]
 
-		// Define a structure to hold the payload for kilometers returned.
-		type KilometersPayload struct {
-			Kilometers float64 `json:"kilometers"`
+		// Define a structure to hold the odometer reading at return.
+		type OdometerPayload struct {
+			Odometer *float64 `json:"odometer"`
 		}
 
-		// Decode the request body into the KilometersPayload structure.
-		var payload KilometersPayload
+		// Decode the request body into the OdometerPayload structure.
+		var payload OdometerPayload
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{"Invalid kilometers payload"})
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid odometer payload"})
 			return
 
 		}
 
-		// Validate that the returned kilometers value is non-negative.
-		if payload.Kilometers < 0 {
+		// Validate that an absolute, non-negative odometer reading was sent.
+		if payload.Odometer == nil || *payload.Odometer < 0 {
 			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(ErrorResponse{"Kilometers parameter must be positive"})
+			json.NewEncoder(w).Encode(ErrorResponse{"Odometer reading is required and must be positive"})
 			return
 		}
 
-		// Check if the specified car exists in the system.
-		exists, car := s.ParkingLotService.IsExist(registration)
-
-		if !exists {
+		// Close the rental and make the car available again.
+		car, rental, err := s.ParkingLotService.ReturnCar(withIfMatch(r).Context(), registration, *payload.Odometer)
+		if errors.Is(err, service.ErrCarNotFound) {
 			// Return a not found response if the car is not found.
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
 			return
 		}
-
-		// Check if the car is currently available (should not be available for return).
-		if car.Available {
+		if errors.Is(err, service.ErrInvalidTransition) {
 			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(ErrorResponse{"Car is already available"})
+			json.NewEncoder(w).Encode(ErrorResponse{"Car is not rented"})
+			return
+		}
+		if errors.Is(err, service.ErrOdometerRollback) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Odometer reading is lower than at checkout"})
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car was modified since it was read"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to return car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to return car"})
 			return
 		}
-
-		// Update the car availability, increase the mileage, and save changes in the database.
-		car.Available = true
-		car.Mileage += float64(payload.Kilometers)
-		s.ParkingLotService.DB.Save(&car)
 
 		// Create a response indicating the successful return of the car.
 		response := CarResponse{
-			Message: "The Car with registration " + registration + " is returned!",
+			Message: "The Car with registration " + car.Registration + " is returned!",
 			Car:     car,
+			Rental:  &rental,
 		}
 
+		w.Header().Set("ETag", carETag(car))
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(response)
 	}
@@ -192,20 +336,152 @@ func GetCar(s *server.Server) http.HandlerFunc {
 		registration := params["registration"]
 
 		// Check if the specified car exists in the system.
-		exists, car := s.ParkingLotService.IsExist(registration)
-
-		if !exists {
+		car, err := s.ParkingLotService.GetCar(r.Context(), registration)
+		if errors.Is(err, service.ErrCarNotFound) {
 			// Return a not found response if the car is not found.
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
 			return
 		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to load car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to load car"})
+			return
+		}
+
+		if notModified(w, r, carETag(car)) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(car)
 	}
 }
 
+// CarUpdate holds the descriptive fields of a car that can be changed after
+// it was added. Fields left out of the request keep their value.
+type CarUpdate struct {
+	CarModel           *string             `json:"model"`
+	Make               *string             `json:"make"`
+	Year               *int                `json:"year"`
+	VIN                *string             `json:"vin"`
+	Colour             *string             `json:"colour"`
+	Seats              *int                `json:"seats"`
+	Transmission       *model.Transmission `json:"transmission"`
+	FuelType           *model.FuelType     `json:"fuel_type"`
+	PurchaseDate       *time.Time          `json:"purchase_date"`
+	PurchasePrice      *float64            `json:"purchase_price"`
+	Category           *string             `json:"category"`
+	Branch             *string             `json:"branch"`
+	NextServiceMileage *float64            `json:"next_service_mileage"`
+	DailyRate          *float64            `json:"daily_rate"`
+}
+
+// apply copies the fields set in the update onto car.
+func (u CarUpdate) apply(car *model.Car) {
+	set := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+	set(&car.CarModel, u.CarModel)
+	set(&car.Make, u.Make)
+	set(&car.VIN, u.VIN)
+	set(&car.Colour, u.Colour)
+	set(&car.Category, u.Category)
+	set(&car.Branch, u.Branch)
+	if u.Year != nil {
+		car.Year = *u.Year
+	}
+	if u.Seats != nil {
+		car.Seats = *u.Seats
+	}
+	if u.Transmission != nil {
+		car.Transmission = *u.Transmission
+	}
+	if u.FuelType != nil {
+		car.FuelType = *u.FuelType
+	}
+	if u.PurchaseDate != nil {
+		car.PurchaseDate = u.PurchaseDate
+	}
+	if u.PurchasePrice != nil {
+		car.PurchasePrice = *u.PurchasePrice
+	}
+	if u.NextServiceMileage != nil {
+		car.NextServiceMileage = *u.NextServiceMileage
+	}
+	if u.DailyRate != nil {
+		car.DailyRate = *u.DailyRate
+	}
+}
+
+// invalidCarError carries a validation message out of the update callback.
+type invalidCarError string
+
+func (e invalidCarError) Error() string { return string(e) }
+
+// UpdateCar handles the PATCH HTTP request to change the descriptive fields
+// of a car.
+func UpdateCar(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		var payload CarUpdate
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid request payload"})
+			return
+		}
+
+		// Apply the changes to the stored car and validate the result.
+		car, err := s.ParkingLotService.UpdateCar(withIfMatch(r).Context(), registration, func(car *model.Car) error {
+			payload.apply(car)
+			if message := model.ValidateCar(car); message != "" {
+				return invalidCarError(message)
+			}
+			return nil
+		})
+		var invalid invalidCarError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{string(invalid)})
+			return
+		}
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if errors.Is(err, service.ErrVINExists) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"A car with this VIN already exists"})
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car was modified since it was read"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to update car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to update car"})
+			return
+		}
+
+		w.Header().Set("ETag", carETag(car))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(car)
+	}
+}
+
 // DeleteCar handles the DELETE HTTP request to delete a specific car from the system.
 func DeleteCar(s *server.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -215,18 +491,30 @@ func DeleteCar(s *server.Server) http.HandlerFunc {
 		params := mux.Vars(r)
 		registration := params["registration"]
 
-		// Check if the specified car exists in the system.
-		exists, car := s.ParkingLotService.IsExist(registration)
-
-		if !exists {
+		// Retire the car and delete it from the database.
+		err := s.ParkingLotService.DeleteCar(withIfMatch(r).Context(), registration)
+		if errors.Is(err, service.ErrCarNotFound) {
 			// Return a not found response if the car is not found.
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
 			return
 		}
-
-		// Delete the car from the database.
-		s.ParkingLotService.DB.Delete(&car)
+		if errors.Is(err, service.ErrInvalidTransition) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car was modified since it was read"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to delete car", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to delete car"})
+			return
+		}
 
 		w.WriteHeader(http.StatusNoContent)
 		json.NewEncoder(w).Encode("The Car with registration " + registration + " is deleted!")
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/server"
+)
+
+// readinessTimeout bounds the time spent on the database checks of Readyz.
+const readinessTimeout = 2 * time.Second
+
+// HealthCheck is the outcome of one check of a health endpoint.
+type HealthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthResponse represents the response structure of the health endpoints.
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// runCheck times a check and converts its error into a HealthCheck.
+func runCheck(check func() error) HealthCheck {
+	start := time.Now()
+	err := check()
+	result := HealthCheck{Status: "ok", LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Healthz handles the GET HTTP request of a liveness probe: it succeeds as
+// long as the process is able to serve requests.
+func Healthz(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(HealthResponse{Status: "ok"})
+	}
+}
+
+// Readyz handles the GET HTTP request of a readiness probe: the database must
+// answer, every table must be migrated and the server must not be shutting down.
+func Readyz(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		checks := map[string]HealthCheck{
+			"database": runCheck(func() error {
+				return s.ParkingLotService.Ping(ctx)
+			}),
+			"migrations": runCheck(func() error {
+				if missing := s.ParkingLotService.PendingMigrations(ctx); len(missing) > 0 {
+					return errors.New("missing tables: " + strings.Join(missing, ", "))
+				}
+				return nil
+			}),
+			"shutdown": runCheck(func() error {
+				if s.ShuttingDown() {
+					return errors.New("server is shutting down")
+				}
+				return nil
+			}),
+		}
+
+		response := HealthResponse{Status: "ok", Checks: checks}
+		status := http.StatusOK
+		for _, check := range checks {
+			if check.Status != "ok" {
+				response.Status = "fail"
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/abdeel07/backend-go-cars/jobs"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/gorilla/mux"
+)
+
+// maxJobRunsLimit caps the job runs listed by one request.
+const maxJobRunsLimit = 500
+
+// JobResponse describes a background job and its most recent run.
+type JobResponse struct {
+	Name     string        `json:"name"`
+	Interval string        `json:"interval"`
+	LastRun  *model.JobRun `json:"last_run"`
+}
+
+// ListJobs handles the GET HTTP request to list the background jobs.
+func ListJobs(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		response := []JobResponse{}
+		for _, job := range s.Jobs.Jobs() {
+			last, err := s.Jobs.LastRun(r.Context(), job.Name)
+			if err != nil {
+				s.Logger.ErrorContext(r.Context(), "Failed to list jobs", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{"Failed to list jobs"})
+				return
+			}
+			response = append(response, JobResponse{Name: job.Name, Interval: job.Interval.String(), LastRun: last})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// ListJobRuns handles the GET HTTP request to list the runs of background
+// jobs, most recent first.
+func ListJobRuns(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		filter := jobs.RunFilter{Job: query.Get("job"), Status: model.JobStatus(query.Get("status")), Limit: 100}
+		switch filter.Status {
+		case "", model.JobRunning, model.JobSucceeded, model.JobFailed:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Query parameter status must be running, succeeded or failed"})
+			return
+		}
+		if value := query.Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > maxJobRunsLimit {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{"Query parameter limit must be between 1 and " + strconv.Itoa(maxJobRunsLimit)})
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		runs, err := s.Jobs.History(r.Context(), filter)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list job runs", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list job runs"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(runs)
+	}
+}
+
+// RunJob handles the POST HTTP request to run a background job now. The run
+// is returned once finished, failed or not.
+func RunJob(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// The job finishes even if the client goes away.
+		run, err := s.Jobs.RunNow(context.WithoutCancel(r.Context()), mux.Vars(r)["name"])
+		if errors.Is(err, jobs.ErrUnknownJob) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Job not found"})
+			return
+		}
+		if errors.Is(err, jobs.ErrLocked) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Job is already running"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to run job", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to run job"})
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(run)
+	}
+}
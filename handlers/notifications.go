@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/server"
+)
+
+// ListNotifications handles the GET HTTP request to list the notifications
+// sent or queued for customers; status=dead gives the dead-letter view.
+func ListNotifications(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		filter := notify.Filter{Status: model.NotificationStatus(query.Get("status"))}
+		switch filter.Status {
+		case "", model.NotificationPending, model.NotificationSent, model.NotificationDead:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Query parameter status must be pending, sent or dead"})
+			return
+		}
+		for name, target := range map[string]*uint{"customer_id": &filter.CustomerID, "rental_id": &filter.RentalID} {
+			if value := query.Get(name); value != "" {
+				id, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(ErrorResponse{"Query parameter " + name + " must be an id"})
+					return
+				}
+				*target = uint(id)
+			}
+		}
+
+		notifications, err := s.ParkingLotService.Notifications.List(r.Context(), filter)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list notifications", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list notifications"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(notifications)
+	}
+}
+
+// RetryNotification handles the POST HTTP request to send a notification
+// again.
+func RetryNotification(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid notification id"})
+			return
+		}
+
+		notification, err := s.ParkingLotService.Notifications.Retry(r.Context(), id)
+		if errors.Is(err, notify.ErrNotificationNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Notification not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to retry notification", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to retry notification"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(notification)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/gorilla/mux"
+)
+
+// OdometerHistory handles the GET HTTP request to list the odometer readings
+// recorded for a car.
+func OdometerHistory(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		readings, err := s.ParkingLotService.OdometerHistory(r.Context(), registration)
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to load odometer history", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to load odometer history"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(readings)
+	}
+}
+
+// PendingReviews handles the GET HTTP request to list the rentals whose
+// driven distance was flagged as implausible.
+func PendingReviews(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		rentals, err := s.ParkingLotService.PendingReviews(r.Context())
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list rentals to review", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list rentals to review"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rentals)
+	}
+}
+
+// ReviewRental handles the PUT HTTP request of a manager clearing the review
+// flag of a rental.
+func ReviewRental(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract the rental id from the request.
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental id"})
+			return
+		}
+
+		// Define a structure to hold the manager's note.
+		type ReviewPayload struct {
+			Note string `json:"note"`
+		}
+
+		var payload ReviewPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid review payload"})
+			return
+		}
+
+		rental, err := s.ParkingLotService.ReviewRental(r.Context(), id, payload.Note)
+		if errors.Is(err, service.ErrRentalNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to review rental", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to review rental"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rental)
+	}
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/openapi"
+	"github.com/abdeel07/backend-go-cars/server"
+)
+
+// OpenAPISpec handles the GET HTTP request for the OpenAPI document of the API.
+func OpenAPISpec(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(openapi.Spec)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// depositRefused writes the response to a rental refused for its deposit,
+// returning false if err is not such a refusal.
+func depositRefused(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrPaymentMethodRequired):
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(ErrorResponse{"A payment method is required for the deposit"})
+	case errors.Is(err, payment.ErrDeclined):
+		w.WriteHeader(http.StatusPaymentRequired)
+		json.NewEncoder(w).Encode(ErrorResponse{"Deposit authorization declined"})
+	default:
+		return false
+	}
+	return true
+}
+
+// RentalPayments handles the GET HTTP request to list the payments of a
+// rental.
+func RentalPayments(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract the rental id from the request.
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental id"})
+			return
+		}
+
+		payments, err := s.ParkingLotService.RentalPayments(r.Context(), id)
+		if errors.Is(err, service.ErrRentalNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list payments", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list payments"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(payments)
+	}
+}
+
+// RefundRental handles the POST HTTP request to refund part of the amount
+// captured for a rental.
+func RefundRental(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract the rental id from the request.
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental id"})
+			return
+		}
+
+		// Define a structure to hold the refunded amount and its reason.
+		type RefundPayload struct {
+			Amount float64 `json:"amount"`
+			Reason string  `json:"reason"`
+		}
+
+		var payload RefundPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid refund payload"})
+			return
+		}
+		if payload.Amount <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Refund amount must be positive"})
+			return
+		}
+
+		refund, err := s.ParkingLotService.RefundRental(r.Context(), id, payload.Amount, payload.Reason)
+		if errors.Is(err, service.ErrRentalNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental not found"})
+			return
+		}
+		if errors.Is(err, service.ErrNothingToRefund) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental has no captured payment to refund"})
+			return
+		}
+		if errors.Is(err, service.ErrRefundExceedsCapture) || errors.Is(err, payment.ErrExceedsCapture) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Refund exceeds the captured amount"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to refund rental", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to refund rental"})
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(refund)
+	}
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// defaultReportDays is the length of the report range when none is given.
+const defaultReportDays = 30
+
+// parseReportRange reads the from and to query parameters, as dates
+// (2006-01-02) or RFC 3339 timestamps. It defaults to the last 30 days.
+func parseReportRange(r *http.Request) (service.ReportRange, string) {
+	period := service.ReportRange{To: time.Now()}
+
+	for name, target := range map[string]*time.Time{"from": &period.From, "to": &period.To} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			parsed, err = time.Parse(time.RFC3339, value)
+		}
+		if err != nil {
+			return period, "Query parameter " + name + " must be a date (2006-01-02) or an RFC 3339 timestamp"
+		}
+		*target = parsed
+	}
+
+	if period.From.IsZero() {
+		period.From = period.To.AddDate(0, 0, -defaultReportDays)
+	}
+	if !period.From.Before(period.To) {
+		return period, "Query parameter from must be before to"
+	}
+
+	return period, ""
+}
+
+// wantsCSV reports whether the client asked for a CSV export, either with
+// ?format=csv or an Accept: text/csv header.
+func wantsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// writeReport encodes report rows as JSON, or as a CSV attachment when the
+// client asked for one.
+func writeReport[T any](w http.ResponseWriter, r *http.Request, name string, header []string, rows []T, record func(T) []string) {
+	if !wantsCSV(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	for _, row := range rows {
+		writer.Write(record(row))
+	}
+	writer.Flush()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// UtilizationReport handles the GET HTTP request for the utilization rate per
+// car, or per model with group_by=model, over a date range.
+func UtilizationReport(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		period, message := parseReportRange(r)
+		if message != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{message})
+			return
+		}
+
+		var rows []service.UtilizationRow
+		var err error
+		switch r.URL.Query().Get("group_by") {
+		case "", "car":
+			rows, err = s.ParkingLotService.UtilizationByCar(r.Context(), period)
+		case "model":
+			rows, err = s.ParkingLotService.UtilizationByModel(r.Context(), period)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Query parameter group_by must be car or model"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to compute utilization", "error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to compute utilization"})
+			return
+		}
+
+		header := []string{"registration", "model", "cars", "rentals", "rented_hours", "utilization"}
+		writeReport(w, r, "utilization", header, rows, func(row service.UtilizationRow) []string {
+			return []string{row.Registration, row.CarModel, strconv.Itoa(row.Cars), strconv.Itoa(row.Rentals),
+				formatFloat(row.RentedHours), strconv.FormatFloat(row.Utilization, 'f', 4, 64)}
+		})
+	}
+}
+
+// RentalReport handles the GET HTTP request for the number, average duration,
+// kilometers and revenue of the rentals ended over a date range.
+func RentalReport(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		period, message := parseReportRange(r)
+		if message != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{message})
+			return
+		}
+
+		stats, err := s.ParkingLotService.RentalStatistics(r.Context(), period)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to compute rental statistics", "error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to compute rental statistics"})
+			return
+		}
+
+		header := []string{"rentals", "average_duration_hours", "kilometers", "revenue"}
+		writeReport(w, r, "rentals", header, []service.RentalStats{stats}, func(row service.RentalStats) []string {
+			return []string{strconv.Itoa(row.Rentals), formatFloat(row.AverageDurationHours),
+				formatFloat(row.Kilometers), formatFloat(row.Revenue)}
+		})
+	}
+}
+
+// IdleCarsReport handles the GET HTTP request for the available cars not
+// rented in the last N days (days=30 by default).
+func IdleCarsReport(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		days := defaultReportDays
+		if value := r.URL.Query().Get("days"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{"Query parameter days must be a positive integer"})
+				return
+			}
+			days = parsed
+		}
+
+		rows, err := s.ParkingLotService.IdleCars(r.Context(), time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list idle cars", "error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list idle cars"})
+			return
+		}
+
+		header := []string{"registration", "model", "last_rented_at", "idle_days"}
+		writeReport(w, r, "idle-cars", header, rows, func(row service.IdleCarRow) []string {
+			lastRentedAt := ""
+			if row.LastRentedAt != nil {
+				lastRentedAt = row.LastRentedAt.Format(time.RFC3339)
+			}
+			return []string{row.Registration, row.CarModel, lastRentedAt, strconv.Itoa(row.IdleDays)}
+		})
+	}
+}
+
+// RevenueReport handles the GET HTTP request for the revenue per car over a
+// date range.
+func RevenueReport(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		period, message := parseReportRange(r)
+		if message != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{message})
+			return
+		}
+
+		rows, err := s.ParkingLotService.RevenueByCar(r.Context(), period)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to compute revenue", "error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to compute revenue"})
+			return
+		}
+
+		header := []string{"registration", "model", "rentals", "kilometers", "revenue"}
+		writeReport(w, r, "revenue", header, rows, func(row service.RevenueRow) []string {
+			return []string{row.Registration, row.CarModel, strconv.Itoa(row.Rentals),
+				formatFloat(row.Kilometers), formatFloat(row.Revenue)}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/gorilla/mux"
+)
+
+// reservationRefused writes the response to a rental refused for its planned
+// end, returning false if err is not such a refusal.
+func reservationRefused(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrInvalidPeriod):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{"Planned end must be in the future"})
+	case errors.Is(err, service.ErrReservationConflict):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(ErrorResponse{reservedMessage(err)})
+	default:
+		return false
+	}
+	return true
+}
+
+// reservedMessage describes the reservation a request conflicts with.
+func reservedMessage(err error) string {
+	var conflict *service.ReservationConflictError
+	if !errors.As(err, &conflict) {
+		return "Car is reserved"
+	}
+	return "Car is reserved from " + conflict.Reservation.StartsAt.Format(time.RFC3339) +
+		" to " + conflict.Reservation.EndsAt.Format(time.RFC3339)
+}
+
+// CreateReservation handles the POST HTTP request to book a car over a
+// future period.
+func CreateReservation(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		var reservation model.Reservation
+		if err := json.NewDecoder(r.Body).Decode(&reservation); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid reservation payload"})
+			return
+		}
+
+		err := s.ParkingLotService.CreateReservation(r.Context(), registration, &reservation)
+		if errors.Is(err, service.ErrInvalidPeriod) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Reservation must end after it starts, in the future"})
+			return
+		}
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if errors.Is(err, service.ErrCustomerNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Customer not found"})
+			return
+		}
+		if errors.Is(err, service.ErrReservationConflict) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{reservedMessage(err)})
+			return
+		}
+		if errors.Is(err, service.ErrRentedDuringPeriod) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car is rented during the period"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to create reservation", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to create reservation"})
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(reservation)
+	}
+}
+
+// ListReservations handles the GET HTTP request to list the upcoming
+// reservations of a car.
+func ListReservations(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		reservations, err := s.ParkingLotService.CarReservations(r.Context(), registration)
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list reservations", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list reservations"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(reservations)
+	}
+}
+
+// CancelReservation handles the DELETE HTTP request to cancel a reservation.
+func CancelReservation(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid reservation id"})
+			return
+		}
+
+		_, err := s.ParkingLotService.CancelReservation(r.Context(), id)
+		if errors.Is(err, service.ErrReservationNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Reservation not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to cancel reservation", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to cancel reservation"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ExtendRental handles the POST HTTP request to move the planned end of a
+// rental in progress later.
+func ExtendRental(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract the rental id from the request.
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid rental id"})
+			return
+		}
+
+		// Define a structure to hold the new planned end.
+		type ExtendPayload struct {
+			PlannedEndAt *time.Time `json:"planned_end_at"`
+		}
+
+		var payload ExtendPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.PlannedEndAt == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid extension payload"})
+			return
+		}
+
+		rental, err := s.ParkingLotService.ExtendRental(r.Context(), id, *payload.PlannedEndAt)
+		if errors.Is(err, service.ErrRentalNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental not found"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPeriod) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Planned end must be later than the current one"})
+			return
+		}
+		if errors.Is(err, service.ErrRentalClosed) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Rental is closed"})
+			return
+		}
+		if errors.Is(err, service.ErrReservationConflict) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{reservedMessage(err)})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to extend rental", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to extend rental"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(rental)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/server"
+)
+
+// Number of results of a car search, when not given and at most.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchCars handles the GET HTTP request to find cars from a partial or
+// misspelled registration or model, best match first.
+func SearchCars(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Query parameter q is required"})
+			return
+		}
+
+		limit := defaultSearchLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > maxSearchLimit {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{"Query parameter limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+				return
+			}
+			limit = parsed
+		}
+
+		matches, err := s.ParkingLotService.SearchCars(r.Context(), query, limit)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to search cars", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to search cars"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(matches)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/gorilla/mux"
+)
+
+// manualStatuses are the statuses that can be set directly through SetCarStatus.
+// Rented, reserved and retired are only reached through their own endpoints.
+var manualStatuses = map[model.CarStatus]bool{
+	model.StatusAvailable:   true,
+	model.StatusMaintenance: true,
+	model.StatusInTransit:   true,
+	model.StatusLost:        true,
+}
+
+// SetCarStatus handles the PUT HTTP request to move a car to maintenance,
+// in transit, lost or back to available.
+func SetCarStatus(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		// Define a structure to hold the requested status.
+		type StatusPayload struct {
+			Status model.CarStatus `json:"status"`
+			Reason string          `json:"reason"`
+		}
+
+		var payload StatusPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid status payload"})
+			return
+		}
+
+		if !manualStatuses[payload.Status] {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Status must be one of available, maintenance, in_transit or lost"})
+			return
+		}
+
+		car, err := s.ParkingLotService.SetStatus(withIfMatch(r).Context(), registration, payload.Status, payload.Reason)
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidTransition) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car was modified since it was read"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to update car status", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to update car status"})
+			return
+		}
+
+		response := CarResponse{
+			Message: "The Car with registration " + car.Registration + " is " + string(car.Status) + "!",
+			Car:     car,
+		}
+
+		w.Header().Set("ETag", carETag(car))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CarStatusHistory handles the GET HTTP request to list the timestamped
+// status transitions of a car.
+func CarStatusHistory(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		// Extract registration parameter from the request.
+		params := mux.Vars(r)
+		registration := params["registration"]
+
+		transitions, err := s.ParkingLotService.StatusHistory(r.Context(), registration)
+		if errors.Is(err, service.ErrCarNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Car not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to load status history", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to load status history"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(transitions)
+	}
+}
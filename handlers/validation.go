@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+)
+
+// parseCarFilter builds a service.CarFilter from the query string of a
+// ListCars request. It returns an error message for malformed values.
+func parseCarFilter(query url.Values) (service.CarFilter, string) {
+	filter := service.CarFilter{
+		Make:         query.Get("make"),
+		CarModel:     query.Get("model"),
+		Colour:       query.Get("colour"),
+		Branch:       query.Get("branch"),
+		Transmission: model.Transmission(query.Get("transmission")),
+		FuelType:     model.FuelType(query.Get("fuel_type")),
+	}
+
+	if vin := query.Get("vin"); vin != "" {
+		normalized, err := model.NormalizeVIN(vin)
+		if err != nil {
+			return filter, "VIN must be 17 characters with a valid check digit"
+		}
+		filter.VIN = normalized
+	}
+	if category := query.Get("category"); category != "" {
+		pattern, err := model.NormalizeCategory(category, true)
+		if err != nil {
+			return filter, "Category must be a valid ACRISS code"
+		}
+		filter.Category = pattern
+	}
+
+	var year int
+	integers := map[string]*int{
+		"year":      &year,
+		"min_year":  &filter.MinYear,
+		"max_year":  &filter.MaxYear,
+		"min_seats": &filter.MinSeats,
+	}
+	for name, target := range integers {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return filter, "Query parameter " + name + " must be a positive integer"
+		}
+		*target = parsed
+	}
+	if year > 0 {
+		filter.MinYear, filter.MaxYear = year, year
+	}
+
+	if status := model.CarStatus(query.Get("status")); status != "" {
+		if !status.Valid() {
+			return filter, "Query parameter status must be a known car status"
+		}
+		filter.Status = status
+	}
+
+	if available := query.Get("available"); available != "" {
+		parsed, err := strconv.ParseBool(available)
+		if err != nil {
+			return filter, "Query parameter available must be true or false"
+		}
+		filter.Available = &parsed
+	}
+
+	return filter, ""
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/webhook"
+	"github.com/gorilla/mux"
+)
+
+// minSecretLength is the shortest secret accepted to sign webhook deliveries.
+const minSecretLength = 16
+
+// validateSubscription checks the URL, event types and secret of a webhook
+// subscription, whose URL may only name a private address when allowPrivate.
+// It returns an error message for the client, or an empty string.
+func validateSubscription(subscription *model.WebhookSubscription, allowPrivate bool) string {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "URL must be an absolute http or https URL"
+	}
+	if !allowPrivate && webhook.CheckURL(target) != nil {
+		return "URL must not point to a private address"
+	}
+	if len(subscription.Events) == 0 {
+		return "At least one event type is required"
+	}
+	for _, event := range subscription.Events {
+		known := event == "*"
+		for _, eventType := range model.EventTypes {
+			known = known || event == eventType
+		}
+		if !known {
+			return "Unknown event type " + event
+		}
+	}
+	if len(subscription.Secret) < minSecretLength {
+		return "Secret must be at least " + strconv.Itoa(minSecretLength) + " characters"
+	}
+
+	return ""
+}
+
+// WebhookRequest holds the fields of a webhook subscription set by the client.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// parseID reads the numeric id path parameter of the request.
+func parseID(r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	return uint(id), err == nil
+}
+
+// CreateWebhook handles the POST HTTP request to subscribe a URL to fleet events.
+func CreateWebhook(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var request WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid webhook payload"})
+			return
+		}
+
+		subscription := model.WebhookSubscription{URL: request.URL, Events: request.Events, Secret: request.Secret}
+
+		if message := validateSubscription(&subscription, s.AllowPrivateWebhooks); message != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{message})
+			return
+		}
+
+		if err := s.ParkingLotService.CreateSubscription(r.Context(), &subscription); err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to create webhook", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to create webhook"})
+			return
+		}
+
+		// The secret is known to the client already; never echo it back.
+		subscription.Secret = ""
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(subscription)
+	}
+}
+
+// ListWebhooks handles the GET HTTP request to list webhook subscriptions.
+func ListWebhooks(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		subscriptions, err := s.ParkingLotService.ListSubscriptions(r.Context())
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list webhooks", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list webhooks"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(subscriptions)
+	}
+}
+
+// DeleteWebhook handles the DELETE HTTP request to remove a webhook subscription.
+func DeleteWebhook(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid webhook id"})
+			return
+		}
+
+		err := s.ParkingLotService.DeleteSubscription(r.Context(), id)
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Webhook not found"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to delete webhook", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to delete webhook"})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListWebhookDeliveries handles the GET HTTP request to list webhook
+// deliveries; status=dead gives the dead-letter view.
+func ListWebhookDeliveries(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		status := model.DeliveryStatus(r.URL.Query().Get("status"))
+		switch status {
+		case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Query parameter status must be pending, delivered or dead"})
+			return
+		}
+
+		deliveries, err := s.ParkingLotService.ListDeliveries(r.Context(), status)
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to list webhook deliveries", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to list webhook deliveries"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(deliveries)
+	}
+}
+
+// RetryWebhookDelivery handles the POST HTTP request to requeue a dead delivery.
+func RetryWebhookDelivery(s *server.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, ok := parseID(r)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{"Invalid delivery id"})
+			return
+		}
+
+		delivery, err := s.ParkingLotService.RetryDelivery(r.Context(), id)
+		if errors.Is(err, service.ErrDeliveryNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{"Delivery not found"})
+			return
+		}
+		if errors.Is(err, service.ErrDeliveryNotDead) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"Only dead deliveries can be retried"})
+			return
+		}
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{"The webhook of this delivery was deleted"})
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorContext(r.Context(), "Failed to retry webhook delivery", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{"Failed to retry webhook delivery"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(delivery)
+	}
+}
@@ -82,3 +82,103 @@ func TestAddCar2(t *testing.T) {
 	fmt.Printf("Test Add Car 2 - HTTP Status Code: %d (Must be 409)\n", response.Code)
 	assert.Equal(t, http.StatusConflict, response.Code)
 }
+
+func TestAddCar3(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a sample car with a VIN whose check digit is wrong.
+	car := model.Car{
+		CarModel:     "Corolla",
+		Make:         "Toyota",
+		Registration: "VIN Registration",
+		VIN:          "1M8GDM9AYKP042788",
+		Mileage:      100,
+	}
+
+	// Convert car to JSON.
+	carJSON, err := json.Marshal(car)
+	assert.NoError(t, err)
+
+	// Create a POST request with the car JSON.
+	request, err := http.NewRequest("POST", "/cars", bytes.NewBuffer(carJSON))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Add Car 3 - With invalid VIN\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Add Car 3 - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestAddCar4(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a sample car with the full fleet management details.
+	car := model.Car{
+		CarModel:      "Corolla",
+		Make:          "Toyota",
+		Year:          2021,
+		Registration:  "VIN Registration",
+		VIN:           "1m8gdm9axkp042788",
+		Colour:        "Blue",
+		Seats:         5,
+		Transmission:  model.Automatic,
+		FuelType:      model.Hybrid,
+		PurchasePrice: 24500,
+		Mileage:       100,
+	}
+
+	// Convert car to JSON.
+	carJSON, err := json.Marshal(car)
+	assert.NoError(t, err)
+
+	// Create a POST request with the car JSON.
+	request, err := http.NewRequest("POST", "/cars", bytes.NewBuffer(carJSON))
+	assert.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Add Car 4 - With make, year and VIN\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Add Car 4 - HTTP Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// Convert the response body to a model.Car.
+	var addedCar model.Car
+	err = json.Unmarshal(response.Body.Bytes(), &addedCar)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Add Car 4 - VIN: %s (Must be upper case)\n", addedCar.VIN)
+	assert.Equal(t, "1M8GDM9AXKP042788", addedCar.VIN)
+}
+
+func TestAddCarSeats(t *testing.T) {
+
+	router := setupRouter()
+
+	fmt.Printf("\n")
+
+	// 0 means the number of seats is unknown, otherwise it is between 1 and 60.
+	for seats, code := range map[int]int{-1: http.StatusBadRequest, 0: http.StatusCreated, 1: http.StatusCreated, 60: http.StatusCreated, 61: http.StatusBadRequest} {
+		body := fmt.Sprintf(`{"model": "Seats", "registration": "SEATS-%d", "seats": %d}`, seats+10, seats)
+		response := send(router, "POST", "/cars", body)
+
+		fmt.Printf("Test Add Car Seats - %d Seats - HTTP Status Code: %d (Must be %d)\n", seats, response.Code, code)
+		assert.Equal(t, code, response.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCarStatus(t *testing.T) {
+
+	router := setupRouter()
+
+	// Send the car with registration "Reg2" to maintenance.
+	payloadBytes, err := json.Marshal(map[string]string{"status": "maintenance", "reason": "brakes"})
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest("PUT", "/cars/Reg2/status", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Set Car Status - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a CarResponse.
+	var statusResponse CarResponse
+	err = json.Unmarshal(response.Body.Bytes(), &statusResponse)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Set Car Status - Status: %s (Must be maintenance)\n", statusResponse.Car.Status)
+	assert.Equal(t, model.StatusMaintenance, statusResponse.Car.Status)
+	assert.False(t, statusResponse.Car.Available)
+}
+
+func TestSetCarStatus2(t *testing.T) {
+
+	router := setupRouter()
+
+	// Try to rent the car with registration "Reg2", which is in maintenance.
+	request, err := http.NewRequest("PUT", "/cars/Reg2/rentals", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Set Car Status 2 - Rent a car in maintenance\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Set Car Status 2 - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestSetCarStatus3(t *testing.T) {
+
+	router := setupRouter()
+
+	// Try to delete the car with registration "Reg3", which is rented.
+	request, err := http.NewRequest("DELETE", "/cars/Reg3", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Set Car Status 3 - Delete a rented car\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Set Car Status 3 - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestCarStatusHistory(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the transitions of the car with registration "Reg2".
+	request, err := http.NewRequest("GET", "/cars/Reg2/transitions", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Car Status History - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a slice of transitions.
+	var transitions []model.CarStatusTransition
+	err = json.Unmarshal(response.Body.Bytes(), &transitions)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Car Status History - Last transition: %s (Must be maintenance)\n", transitions[len(transitions)-1].To)
+	assert.Equal(t, model.StatusMaintenance, transitions[len(transitions)-1].To)
+}
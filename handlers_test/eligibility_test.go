@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/handlers"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// addDriver registers a customer of the given age who has held a licence for
+// the given number of years.
+func addDriver(t *testing.T, s *server.Server, licence string, age, licenceYears int) model.Customer {
+	born := time.Now().AddDate(-age, 0, -1)
+	issued := time.Now().AddDate(-licenceYears, 0, -1)
+	customer := model.Customer{
+		Name:            "Driver " + licence,
+		LicenceNumber:   licence,
+		DateOfBirth:     &born,
+		LicenceIssuedAt: &issued,
+	}
+
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	assert.NoError(t, s.ParkingLotService.CreateCustomer(ctx, &customer))
+	return customer
+}
+
+// rentFor serves a PUT request renting the car for the customer and the
+// additional drivers.
+func rentFor(router http.Handler, registration string, customer uint, drivers ...uint) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(map[string]interface{}{"customer_id": customer, "additional_driver_ids": drivers})
+	return send(router, "PUT", "/cars/"+registration+"/rentals", string(payload))
+}
+
+func TestRentYoungDriver(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Eligible", "registration": "EL-100-AA"}`).Code)
+	driver := addDriver(t, parkingLotServer, "YOUNG-1", 19, 1)
+
+	// A 19 year old is below the default minimum age of 21.
+	response := rentFor(router, "EL-100-AA", driver.ID)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rent Young Driver - HTTP Status Code: %d (Must be 422)\n", response.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+
+	var refused handlers.EligibilityResponse
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &refused))
+	if assert.Len(t, refused.Failures, 1) {
+		assert.Equal(t, eligibility.RuleMinAge, refused.Failures[0].Rule)
+		assert.Equal(t, driver.ID, refused.Failures[0].CustomerID)
+	}
+}
+
+func TestRentWithAdditionalDrivers(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.ParkingLotService.Eligibility.YoungDriverSurcharge = 12
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Eligible", "registration": "EL-200-AA"}`).Code)
+	customer := addDriver(t, parkingLotServer, "MAIN-2", 40, 20)
+	second := addDriver(t, parkingLotServer, "SECOND-2", 23, 3)
+
+	response := rentFor(router, "EL-200-AA", customer.ID, second.ID)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rent With Additional Drivers - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// The additional driver is under 25, so the young driver surcharge applies.
+	var rented struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rented))
+	assert.Equal(t, 12.0, rented.Rental.YoungDriverSurcharge)
+	if assert.Len(t, rented.Rental.AdditionalDrivers, 1) {
+		assert.Equal(t, second.ID, rented.Rental.AdditionalDrivers[0].CustomerID)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Eligible", "registration": "EL-300-AA"}`).Code)
+	driver := addDriver(t, parkingLotServer, "BLOCK-3", 40, 20)
+
+	// An entry needs a licence number or an email.
+	request, err := http.NewRequest("POST", "/blocklist", bytes.NewBufferString(`{"reason": "unpaid damage"}`))
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Blocklist - Empty Entry Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	// Block the licence, written with other separators.
+	request, err = http.NewRequest("POST", "/blocklist", bytes.NewBufferString(`{"licence_number": "block 3", "reason": "unpaid damage"}`))
+	assert.NoError(t, err)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Blocklist - Block Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var entry model.BlockedDriver
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &entry))
+
+	response = rentFor(router, "EL-300-AA", driver.ID)
+
+	fmt.Printf("Test Blocklist - Rent Status Code: %d (Must be 422)\n", response.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.Code)
+
+	var refused handlers.EligibilityResponse
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &refused))
+	if assert.Len(t, refused.Failures, 1) {
+		assert.Equal(t, eligibility.RuleBlocklist, refused.Failures[0].Rule)
+	}
+
+	// Once unblocked, the driver may rent the car.
+	request, err = http.NewRequest("DELETE", fmt.Sprintf("/blocklist/%d", entry.ID), nil)
+	assert.NoError(t, err)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Blocklist - Unblock Status Code: %d (Must be 204)\n", response.Code)
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = rentFor(router, "EL-300-AA", driver.ID)
+
+	fmt.Printf("Test Blocklist - Rent Again Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
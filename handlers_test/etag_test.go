@@ -0,0 +1,144 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionalGetCar(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "POST", "/cars", `{"model": "ETag", "registration": "RegETag", "category": "IDAR"}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// The first read returns the car with the tag of its version.
+	response = send(router, "GET", "/cars/RegETag", "")
+	etag := response.Header().Get("ETag")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Conditional Get Car - HTTP Status Code: %d, ETag: %s (Must be 200, \"1\")\n", response.Code, etag)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, `"1"`, etag)
+
+	// Reading again with the tag returns no body.
+	response = send(router, "GET", "/cars/RegETag", "", "If-None-Match", etag)
+
+	fmt.Printf("Test Conditional Get Car - HTTP Status Code: %d (Must be 304)\n", response.Code)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+	assert.Empty(t, response.Body.Bytes())
+
+	// A stale tag returns the car.
+	response = send(router, "GET", "/cars/RegETag", "", "If-None-Match", `"0"`)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestConditionalListCars(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "GET", "/cars?category=IDAR", "")
+	etag := response.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response = send(router, "GET", "/cars?category=IDAR", "", "If-None-Match", etag)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Conditional List Cars - HTTP Status Code: %d (Must be 304)\n", response.Code)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+
+	// Any change to a listed car changes the tag of the list.
+	response = send(router, "PATCH", "/cars/RegETag", `{"colour": "red"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response = send(router, "GET", "/cars?category=IDAR", "", "If-None-Match", etag)
+
+	fmt.Printf("Test Conditional List Cars - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NotEqual(t, etag, response.Header().Get("ETag"))
+}
+
+func TestIfMatchUpdateCar(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "GET", "/cars/RegETag", "")
+	etag := response.Header().Get("ETag")
+
+	// Update the car with the tag just read.
+	response = send(router, "PATCH", "/cars/RegETag", `{"make": "Renault", "daily_rate": 35}`, "If-Match", etag)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test If-Match Update Car - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var car model.Car
+	err := json.Unmarshal(response.Body.Bytes(), &car)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renault", car.Make)
+	assert.Equal(t, "red", car.Colour)
+	assert.Equal(t, 35.0, car.DailyRate)
+	assert.NotEqual(t, etag, response.Header().Get("ETag"))
+
+	// A second writer holding the old tag is rejected.
+	response = send(router, "PATCH", "/cars/RegETag", `{"make": "Peugeot"}`, "If-Match", etag)
+
+	fmt.Printf("Test If-Match Update Car - HTTP Status Code: %d (Must be 412)\n", response.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+	// Invalid fields are rejected before anything is saved.
+	response = send(router, "PATCH", "/cars/RegETag", `{"seats": 99}`)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	response = send(router, "GET", "/cars/RegETag", "")
+	err = json.Unmarshal(response.Body.Bytes(), &car)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renault", car.Make)
+}
+
+func TestIfMatchLifecycle(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "GET", "/cars/RegETag", "")
+	etag := response.Header().Get("ETag")
+
+	// Renting with a stale tag fails and leaves the car available.
+	response = send(router, "PUT", "/cars/RegETag/rentals", "", "If-Match", `"1"`)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test If-Match Lifecycle - Rent HTTP Status Code: %d (Must be 412)\n", response.Code)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+	response = send(router, "PUT", "/cars/RegETag/rentals", "", "If-Match", etag)
+
+	fmt.Printf("Test If-Match Lifecycle - Rent HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	etag = response.Header().Get("ETag")
+
+	// Weak tags never match If-Match.
+	response = send(router, "PUT", "/cars/RegETag/returns", `{"odometer": 20}`, "If-Match", "W/"+etag)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+	response = send(router, "PUT", "/cars/RegETag/returns", `{"odometer": 20}`, "If-Match", etag)
+
+	fmt.Printf("Test If-Match Lifecycle - Return HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// The status change and the deletion are checked the same way.
+	response = send(router, "PUT", "/cars/RegETag/status", `{"status": "maintenance"}`, "If-Match", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+	response = send(router, "DELETE", "/cars/RegETag", "", "If-Match", `"1", `+etag)
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+
+	response = send(router, "DELETE", "/cars/RegETag", "", "If-Match", "*")
+
+	fmt.Printf("Test If-Match Lifecycle - Delete HTTP Status Code: %d (Must be 204)\n", response.Code)
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
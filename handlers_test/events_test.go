@@ -0,0 +1,190 @@
+package handlers_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/events"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamEvent is a message read from the event stream.
+type streamEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// lastStreamEventID is the id of the event received by TestStreamCarEvents.
+var lastStreamEventID string
+
+// readStreamEvent reads the next message of an event stream, skipping
+// heartbeat comments.
+func readStreamEvent(scanner *bufio.Scanner) (streamEvent, bool) {
+	var event streamEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" && event.Event != "":
+			return event, true
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return event, false
+}
+
+// setStatus changes the status of a car through the router.
+func setStatus(t *testing.T, router http.Handler, registration string, status model.CarStatus) {
+	payloadBytes, _ := json.Marshal(map[string]string{"status": string(status)})
+	request, err := http.NewRequest("PUT", "/cars/"+registration+"/status", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestStreamCarEvents(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.Events.Heartbeat = 20 * time.Millisecond
+
+	// Load the events written by the previous tests.
+	assert.NoError(t, parkingLotServer.Events.Poll(context.Background()))
+
+	testServer := httptest.NewServer(router)
+	defer testServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Open the stream of the events of the car "Reg2".
+	request, err := http.NewRequestWithContext(ctx, "GET", testServer.URL+"/events/cars?registration=Reg2", nil)
+	assert.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer response.Body.Close()
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Stream Car Events - HTTP Status Code: %d (Must be 200)\n", response.StatusCode)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	// Wait for a heartbeat, so the stream is known to be subscribed.
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() && scanner.Text() != ": heartbeat" {
+	}
+
+	// Change the status of another car, then of "Reg2", and publish.
+	setStatus(t, router, "Reg1", model.StatusMaintenance)
+	setStatus(t, router, "Reg1", model.StatusAvailable)
+	setStatus(t, router, "Reg2", model.StatusAvailable)
+	assert.NoError(t, parkingLotServer.Events.Poll(context.Background()))
+
+	event, ok := readStreamEvent(scanner)
+	assert.True(t, ok)
+
+	fmt.Printf("Test Stream Car Events - Event: %s (Must be car.status_changed)\n", event.Event)
+	assert.Equal(t, model.EventCarStatusChanged, event.Event)
+	assert.Contains(t, event.Data, `"registration":"Reg2"`)
+	assert.Contains(t, event.Data, `"status":"available"`)
+
+	lastStreamEventID = event.ID
+}
+
+func TestStreamCarEventsResume(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	assert.NoError(t, parkingLotServer.Events.Poll(context.Background()))
+
+	// Send "Reg2" back to maintenance while no client is connected.
+	setStatus(t, router, "Reg2", model.StatusMaintenance)
+	assert.NoError(t, parkingLotServer.Events.Poll(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Reconnect with the id of the last event received.
+	request, err := http.NewRequestWithContext(ctx, "GET", "/events/cars?registration=Reg2", nil)
+	assert.NoError(t, err)
+	request.Header.Set("Last-Event-ID", lastStreamEventID)
+
+	// Serve the HTTP request until the replayed events are written.
+	response := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(response, request)
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Stream Car Events Resume - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	event, ok := readStreamEvent(bufio.NewScanner(response.Body))
+	assert.True(t, ok)
+
+	// The missed event is replayed first.
+	previousID, _ := strconv.Atoi(lastStreamEventID)
+	eventID, _ := strconv.Atoi(event.ID)
+	fmt.Printf("Test Stream Car Events Resume - Event: %s (Must be car.status_changed)\n", event.Event)
+	assert.Equal(t, model.EventCarStatusChanged, event.Event)
+	assert.Greater(t, eventID, previousID)
+	assert.Contains(t, event.Data, `"status":"maintenance"`)
+}
+
+func TestStreamLateCommit(t *testing.T) {
+
+	_, parkingLotServer := setupServer()
+	db := parkingLotServer.ParkingLotService.DB
+	broker := events.NewBroker(db, slog.Default(), 10)
+	assert.NoError(t, broker.Poll(context.Background()))
+
+	subscription, _, _ := broker.Subscribe(events.Filter{}, 0, false)
+	defer broker.Unsubscribe(subscription)
+
+	var last model.OutboxEvent
+	assert.NoError(t, db.Order("id DESC").First(&last).Error)
+
+	// The event with the later id commits first.
+	later := model.OutboxEvent{ID: last.ID + 2, Type: model.EventCarUpdated, Payload: "{}"}
+	assert.NoError(t, db.Create(&later).Error)
+	assert.NoError(t, broker.Poll(context.Background()))
+	assert.Equal(t, later.ID, (<-subscription.C).ID)
+
+	earlier := model.OutboxEvent{ID: last.ID + 1, Type: model.EventCarUpdated, Payload: "{}"}
+	assert.NoError(t, db.Create(&earlier).Error)
+	assert.NoError(t, broker.Poll(context.Background()))
+
+	var received uint
+	select {
+	case event := <-subscription.C:
+		received = event.ID
+	default:
+	}
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Stream Late Commit - Event ID: %d (Must be %d)\n", received, earlier.ID)
+	assert.Equal(t, earlier.ID, received)
+}
@@ -0,0 +1,182 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/fleetctl"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// runFleetctl runs a fleetctl command on backend and returns its output.
+func runFleetctl(backend fleetctl.Backend, asJSON bool, args ...string) (string, error) {
+	var out bytes.Buffer
+	err := fleetctl.Run(context.Background(), backend, fleetctl.Printer{Out: &out, JSON: asJSON}, args)
+	return out.String(), err
+}
+
+func TestFleetctlAPI(t *testing.T) {
+
+	api := httptest.NewServer(setupRouter())
+	defer api.Close()
+	backend := fleetctl.NewAPIBackend(api.URL)
+
+	// Add a car and find it in the table of its category.
+	_, err := runFleetctl(backend, false, "add", "-registration", "RegCLI", "-model", "Clio", "-make", "Renault", "-category", "UDAR", "-mileage", "100")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Fleetctl API - Add Error: %v (Must be <nil>)\n", err)
+	assert.NoError(t, err)
+
+	out, err := runFleetctl(backend, false, "list", "-category", "UDAR")
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, []string{"REGISTRATION", "MODEL", "MAKE", "CATEGORY", "BRANCH", "STATUS", "MILEAGE", "DAILY_RATE"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"RegCLI", "Clio", "Renault", "UDAR", "available", "100", "0"}, strings.Fields(lines[1]))
+	}
+
+	// Rent and return it, with flags after the registration.
+	out, err = runFleetctl(backend, true, "rent", "RegCLI")
+	assert.NoError(t, err)
+
+	var result fleetctl.RentalResult
+	assert.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, model.StatusRented, result.Car.Status)
+	assert.NotZero(t, result.Rental.ID)
+
+	out, err = runFleetctl(backend, true, "return", "RegCLI", "-odometer", "130")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal([]byte(out), &result))
+
+	fmt.Printf("Test Fleetctl API - Return Distance: %.0f (Must be 30)\n", result.Rental.Distance)
+	assert.Equal(t, 30.0, result.Rental.Distance)
+	assert.Equal(t, model.StatusAvailable, result.Car.Status)
+
+	// Errors of the API are reported with their message.
+	_, err = runFleetctl(backend, false, "rent", "RegMissing")
+	var apiErr *fleetctl.APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, 404, apiErr.StatusCode)
+	}
+
+	out, err = runFleetctl(backend, true, "report", "revenue", "-from", "2000-01-01")
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"registration": "RegCLI"`)
+}
+
+func TestFleetctlDB(t *testing.T) {
+
+	db, err := service.InitializeDB("root:@tcp(127.0.0.1:3306)/parking_lot_test?charset=utf8&parseTime=True&loc=Local")
+	assert.NoError(t, err)
+	backend := &fleetctl.DBBackend{Service: service.NewParkingLotService(db)}
+
+	// The database backend sees the car added through the API.
+	out, err := runFleetctl(backend, true, "list", "-category", "UDAR", "-available", "true")
+	assert.NoError(t, err)
+
+	var cars []model.Car
+	assert.NoError(t, json.Unmarshal([]byte(out), &cars))
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Fleetctl DB - Cars: %d (Must be 1)\n", len(cars))
+	assert.Len(t, cars, 1)
+
+	out, err = runFleetctl(backend, false, "rent", "-category", "UDAR", "-strategy", "lowest_mileage")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "RegCLI")
+
+	_, err = runFleetctl(backend, false, "rent", "-category", "UDAR")
+	assert.ErrorIs(t, err, service.ErrNoCarAvailable)
+
+	_, err = runFleetctl(backend, false, "return", "RegCLI", "-odometer", "100")
+	assert.ErrorIs(t, err, service.ErrOdometerRollback)
+
+	_, err = runFleetctl(backend, false, "return", "RegCLI", "-odometer", "140")
+	assert.NoError(t, err)
+
+	// Reports run on the database directly.
+	out, err = runFleetctl(backend, false, "report", "utilization", "-from", "2000-01-01")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "RegCLI")
+
+	_, err = runFleetctl(backend, false, "report", "weekly")
+	assert.ErrorIs(t, err, fleetctl.ErrUnknownReport)
+
+	out, err = runFleetctl(backend, false, "delete", "RegCLI")
+
+	fmt.Printf("Test Fleetctl DB - Delete Output: %q (Must be \"Car RegCLI deleted\\n\")\n", out)
+	assert.NoError(t, err)
+	assert.Equal(t, "Car RegCLI deleted\n", out)
+}
+
+func TestFleetctlImport(t *testing.T) {
+
+	api := httptest.NewServer(setupRouter())
+	defer api.Close()
+	backend := fleetctl.NewAPIBackend(api.URL)
+
+	csv := "registration,model,make,category,mileage,purchase_date\n" +
+		"RegCSV1,Clio,Renault,UDAR,1200,2023-05-01\n" +
+		"RegCSV2,208,Peugeot,UDAR,ten,\n" +
+		"RegCSV1,Clio,Renault,UDAR,,\n" +
+		"RegCSV3,208,Peugeot,UDAR,,\n"
+
+	// Bad lines are reported and the others imported.
+	results, err := fleetctl.ImportCars(context.Background(), backend, strings.NewReader(csv))
+	assert.NoError(t, err)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Fleetctl Import - Lines: %d (Must be 4)\n", len(results))
+	if assert.Len(t, results, 4) {
+		assert.Empty(t, results[0].Error)
+		assert.Equal(t, "mileage must be a number", results[1].Error)
+		assert.Equal(t, 3, results[1].Line)
+		assert.Contains(t, results[2].Error, "HTTP 409")
+		assert.Empty(t, results[3].Error)
+	}
+
+	cars, err := backend.ListCars(context.Background(), fleetctl.CarFilter{Category: "UDAR"})
+	assert.NoError(t, err)
+	if assert.Len(t, cars, 2) {
+		assert.Equal(t, 1200.0, cars[0].Mileage)
+		assert.Equal(t, "2023-05-01", cars[0].PurchaseDate.Format("2006-01-02"))
+	}
+
+	_, err = fleetctl.ImportCars(context.Background(), backend, strings.NewReader("registration,wheels\n"))
+	assert.Error(t, err)
+
+	for _, registration := range []string{"RegCSV1", "RegCSV3"} {
+		assert.NoError(t, backend.DeleteCar(context.Background(), registration))
+	}
+}
+
+func TestFleetctlMain(t *testing.T) {
+
+	api := httptest.NewServer(setupRouter())
+	defer api.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := fleetctl.Main([]string{"-api", api.URL, "-o", "json", "list", "-category", "CDAR"}, &stdout, &stderr)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Fleetctl Main - Exit Code: %d (Must be 0)\n", code)
+	assert.Equal(t, 0, code)
+
+	var cars []model.Car
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &cars))
+	assert.NotEmpty(t, cars)
+
+	// Usage errors exit with 2, failed commands with 1.
+	assert.Equal(t, 2, fleetctl.Main(nil, &stdout, &stderr))
+	assert.Equal(t, 2, fleetctl.Main([]string{"-api", api.URL, "rent"}, &stdout, &stderr))
+	assert.Equal(t, 1, fleetctl.Main([]string{"-api", api.URL, "migrate"}, &stdout, &stderr))
+	assert.Equal(t, 1, fleetctl.Main([]string{"-api", api.URL, "delete", "RegMissing"}, &stdout, &stderr))
+}
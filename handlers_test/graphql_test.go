@@ -0,0 +1,210 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// GraphQLResponse represents the response structure of the GraphQL endpoint.
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// graphQL posts a query to the GraphQL endpoint and decodes its data into out.
+func graphQL(t *testing.T, router http.Handler, query string, variables map[string]interface{}, out interface{}) GraphQLResponse {
+	payloadBytes, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	request, err := http.NewRequest("POST", "/graphql", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var result GraphQLResponse
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+	if out != nil && len(result.Data) > 0 {
+		assert.NoError(t, json.Unmarshal(result.Data, out))
+	}
+	return result
+}
+
+func TestGraphQLCars(t *testing.T) {
+
+	router := setupRouter()
+
+	// Query the first car of the category CDAR.
+	query := `query($after: String) {
+		cars(filter: {category: "CDAR"}, first: 1, after: $after) {
+			nodes { registration status }
+			pageInfo { endCursor hasNextPage }
+		}
+	}`
+	var page struct {
+		Cars struct {
+			Nodes    []struct{ Registration string }
+			PageInfo struct {
+				EndCursor   string
+				HasNextPage bool
+			}
+		}
+	}
+	result := graphQL(t, router, query, nil, &page)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test GraphQL Cars - Number of errors: %d (Must be 0)\n", len(result.Errors))
+	assert.Empty(t, result.Errors)
+	assert.Len(t, page.Cars.Nodes, 1)
+	assert.True(t, page.Cars.PageInfo.HasNextPage)
+
+	// Query the next page with the cursor.
+	first := page.Cars.Nodes[0].Registration
+	graphQL(t, router, query, map[string]interface{}{"after": page.Cars.PageInfo.EndCursor}, &page)
+
+	fmt.Printf("Test GraphQL Cars - Next page: %d car (Must be 1)\n", len(page.Cars.Nodes))
+	if assert.Len(t, page.Cars.Nodes, 1) {
+		assert.NotEqual(t, first, page.Cars.Nodes[0].Registration)
+	}
+	assert.False(t, page.Cars.PageInfo.HasNextPage)
+}
+
+func TestGraphQLRentAndReturn(t *testing.T) {
+
+	router := setupRouter()
+
+	// Add a car in a category of its own.
+	request, err := http.NewRequest("POST", "/cars", bytes.NewBuffer([]byte(`{"model": "Graph", "registration": "RegGQL", "category": "EDAR", "daily_rate": 40}`)))
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// Register a customer.
+	var added struct {
+		AddCustomer struct{ ID string }
+	}
+	result := graphQL(t, router, `mutation { addCustomer(name: "Ada Lovelace", email: "ada@example.com", dateOfBirth: "1985-12-10T00:00:00Z", licenceIssuedAt: "2005-06-01T00:00:00Z") { id } }`, nil, &added)
+	assert.Empty(t, result.Errors)
+
+	// Rent a car of the category for the customer.
+	var rented struct {
+		RentCar struct {
+			Car    struct{ Registration, Status string }
+			Rental struct{ Customer struct{ Name string } }
+		}
+	}
+	result = graphQL(t, router, `mutation($customer: ID) {
+		rentCar(category: "EDAR", customerId: $customer) {
+			car { registration status }
+			rental { customer { name } }
+		}
+	}`, map[string]interface{}{"customer": added.AddCustomer.ID}, &rented)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test GraphQL Rent - Registration: %s (Must be RegGQL)\n", rented.RentCar.Car.Registration)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "RegGQL", rented.RentCar.Car.Registration)
+	assert.Equal(t, "rented", rented.RentCar.Car.Status)
+	assert.Equal(t, "Ada Lovelace", rented.RentCar.Rental.Customer.Name)
+
+	// The customer now has a rental in progress.
+	var customer struct {
+		Customer struct {
+			Rentals []struct {
+				Car struct {
+					Registration  string
+					CurrentRental *struct{ ID string }
+				}
+			}
+		}
+	}
+	graphQL(t, router, `query($id: ID!) { customer(id: $id) { rentals { car { registration currentRental { id } } } } }`,
+		map[string]interface{}{"id": added.AddCustomer.ID}, &customer)
+	if assert.Len(t, customer.Customer.Rentals, 1) {
+		assert.NotNil(t, customer.Customer.Rentals[0].Car.CurrentRental)
+	}
+
+	// Return the car.
+	var returned struct {
+		ReturnCar struct {
+			Car    struct{ Status string }
+			Rental struct{ Distance float64 }
+		}
+	}
+	result = graphQL(t, router, `mutation { returnCar(registration: "RegGQL", odometer: 120) { car { status } rental { distance } } }`, nil, &returned)
+
+	fmt.Printf("Test GraphQL Return - Status: %s (Must be available)\n", returned.ReturnCar.Car.Status)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "available", returned.ReturnCar.Car.Status)
+	assert.Equal(t, 120.0, returned.ReturnCar.Rental.Distance)
+
+	// Returning it again is refused.
+	result = graphQL(t, router, `mutation { returnCar(registration: "RegGQL", odometer: 130) { car { status } } }`, nil, nil)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, "CONFLICT", result.Errors[0].Extensions.Code)
+	}
+
+	// Rented again, the car has two rentals, of which only the latest is
+	// asked for.
+	assert.Equal(t, http.StatusOK, send(router, "PUT", "/cars/RegGQL/rentals", "").Code)
+	var car struct {
+		Car struct {
+			Latest        []struct{ ID string }
+			Rentals       []struct{ ID string }
+			CurrentRental *struct{ ID string }
+		}
+	}
+	result = graphQL(t, router, `{ car(registration: "RegGQL") { latest: rentals(first: 1) { id } rentals { id } currentRental { id } } }`, nil, &car)
+
+	fmt.Printf("Test GraphQL Rentals - Latest: %d (Must be 1)\n", len(car.Car.Latest))
+	assert.Empty(t, result.Errors)
+	assert.Len(t, car.Car.Rentals, 2)
+	if assert.Len(t, car.Car.Latest, 1) && assert.NotNil(t, car.Car.CurrentRental) {
+		assert.Equal(t, car.Car.CurrentRental.ID, car.Car.Latest[0].ID)
+		assert.Equal(t, car.Car.Rentals[0].ID, car.Car.Latest[0].ID)
+	}
+}
+
+func TestGraphQLBatching(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// Count the SELECT queries sent to the database, not the subqueries
+	// built into them.
+	queries := 0
+	parkingLotServer.ParkingLotService.DB.Callback().Query().After("gorm:query").Register("test:count_queries", func(db *gorm.DB) {
+		if !db.DryRun {
+			queries++
+		}
+	})
+
+	// Each car resolves its rentals and their customers.
+	var page struct {
+		Cars struct {
+			Nodes []struct {
+				Rentals []struct{ ID string }
+			}
+		}
+	}
+	result := graphQL(t, router, `{ cars(first: 20) { nodes { rentals { id customer { name } } currentRental { id } } } }`, nil, &page)
+	assert.Empty(t, result.Errors)
+
+	// One query for the cars, one for their latest rentals, one for their
+	// current rental and one for the customers.
+	fmt.Printf("\n")
+	fmt.Printf("Test GraphQL Batching - Queries for %d cars: %d (Must be <= 4)\n", len(page.Cars.Nodes), queries)
+	assert.Greater(t, len(page.Cars.Nodes), 4)
+	assert.LessOrEqual(t, queries, 4)
+}
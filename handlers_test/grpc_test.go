@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/fleetpb"
+	"github.com/abdeel07/backend-go-cars/grpcapi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupGRPCClient serves the FleetService over an in-memory connection and
+// returns a client for it.
+func setupGRPCClient(t *testing.T) fleetpb.FleetServiceClient {
+	_, parkingLotServer := setupServer()
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpcapi.NewGRPCServer(parkingLotServer)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return fleetpb.NewFleetServiceClient(conn)
+}
+
+func TestGRPCGetCar(t *testing.T) {
+
+	client := setupGRPCClient(t)
+
+	// Get the car with registration "Reg1".
+	car, err := client.GetCar(context.Background(), &fleetpb.GetCarRequest{Registration: "Reg1"})
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test gRPC Get Car - Error: %v (Must be nil)\n", err)
+	assert.NoError(t, err)
+	assert.Equal(t, "Model1", car.GetModel())
+
+	// Get a car which does not exist.
+	_, err = client.GetCar(context.Background(), &fleetpb.GetCarRequest{Registration: "RegXXX"})
+
+	fmt.Printf("Test gRPC Get Car - Code: %s (Must be NotFound)\n", status.Code(err))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCListCars(t *testing.T) {
+
+	client := setupGRPCClient(t)
+
+	// Stream the cars of the category CDAR.
+	stream, err := client.ListCars(context.Background(), &fleetpb.ListCarsRequest{Category: "CDAR"})
+	assert.NoError(t, err)
+
+	var registrations []string
+	for {
+		car, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		registrations = append(registrations, car.GetRegistration())
+	}
+
+	fmt.Printf("\n")
+	fmt.Printf("Test gRPC List Cars - Number of cars: %d (Must be 2)\n", len(registrations))
+	assert.ElementsMatch(t, []string{"Reg4", "Reg5"}, registrations)
+}
+
+func TestGRPCErrorCodes(t *testing.T) {
+
+	client := setupGRPCClient(t)
+	ctx := context.Background()
+
+	// Adding a car with an existing registration.
+	_, err := client.AddCar(ctx, &fleetpb.AddCarRequest{Car: &fleetpb.Car{Model: "Model1", Registration: "Reg1"}})
+
+	fmt.Printf("\n")
+	fmt.Printf("Test gRPC Error Codes - Duplicate: %s (Must be AlreadyExists)\n", status.Code(err))
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Adding a car without registration.
+	_, err = client.AddCar(ctx, &fleetpb.AddCarRequest{Car: &fleetpb.Car{Model: "Model1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Renting a car which is already rented.
+	_, err = client.RentCar(ctx, &fleetpb.RentCarRequest{Registration: "Reg3"})
+
+	fmt.Printf("Test gRPC Error Codes - Rented: %s (Must be FailedPrecondition)\n", status.Code(err))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Returning a car which does not exist.
+	_, err = client.ReturnCar(ctx, &fleetpb.ReturnCarRequest{Registration: "RegXXX", Odometer: 10})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
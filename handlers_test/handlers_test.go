@@ -1,7 +1,10 @@
 package handlers_test
 
 import (
+	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -22,7 +25,10 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
-	service.MigrateDB(db)
+	if err := service.MigrateDB(db); err != nil {
+		fmt.Println("Error migrating database:", err)
+		os.Exit(1)
+	}
 
 	seedTestData(db)
 
@@ -39,9 +45,11 @@ func TestMain(m *testing.M) {
 // seedTestData inserts test data into the database.
 func seedTestData(db *gorm.DB) {
 	cars := []model.Car{
-		{CarModel: "Model1", Registration: "Reg1", Mileage: 500, Available: true},
-		{CarModel: "Model2", Registration: "Reg2", Mileage: 160, Available: true},
-		{CarModel: "Model3", Registration: "Reg3", Mileage: 1000, Available: false},
+		{CarModel: "Model1", Registration: "Reg1", Mileage: 500, Status: model.StatusAvailable},
+		{CarModel: "Model2", Registration: "Reg2", Mileage: 160, Status: model.StatusAvailable},
+		{CarModel: "Model3", Registration: "Reg3", Mileage: 1000, Status: model.StatusRented},
+		{CarModel: "Model4", Registration: "Reg4", Category: "CDAR", Mileage: 300, Status: model.StatusAvailable},
+		{CarModel: "Model5", Registration: "Reg5", Category: "CDAR", Mileage: 900, Status: model.StatusAvailable},
 	}
 
 	for _, car := range cars {
@@ -51,6 +59,21 @@ func seedTestData(db *gorm.DB) {
 
 // clearTestData deletes all test data from the database.
 func clearTestData(db *gorm.DB) {
+	db.Exec("DELETE FROM notifications")
+	db.Exec("DELETE FROM job_runs")
+	db.Exec("DELETE FROM job_locks")
+	db.Exec("DELETE FROM idempotency_keys")
+	db.Exec("DELETE FROM webhook_deliveries")
+	db.Exec("DELETE FROM webhook_subscriptions")
+	db.Exec("DELETE FROM outbox_events")
+	db.Exec("DELETE FROM odometer_readings")
+	db.Exec("DELETE FROM payments")
+	db.Exec("DELETE FROM reservations")
+	db.Exec("DELETE FROM rental_drivers")
+	db.Exec("DELETE FROM blocked_drivers")
+	db.Exec("DELETE FROM rentals")
+	db.Exec("DELETE FROM customers")
+	db.Exec("DELETE FROM car_status_transitions")
 	db.Exec("DELETE FROM cars")
 }
 
@@ -61,6 +84,13 @@ type CarResponse struct {
 }
 
 func setupRouter() *mux.Router {
+	router, _ := setupServer()
+	return router
+}
+
+// setupServer is like setupRouter but also returns the server, for tests
+// that need to change its state.
+func setupServer() (*mux.Router, *server.Server) {
 	router := mux.NewRouter()
 
 	db, err := service.InitializeDB("root:@tcp(127.0.0.1:3306)/parking_lot_test?charset=utf8&parseTime=True&loc=Local")
@@ -68,11 +98,35 @@ func setupRouter() *mux.Router {
 		fmt.Println("Error initializing database:", err)
 	}
 
-	service.MigrateDB(db)
+	if err := service.MigrateDB(db); err != nil {
+		fmt.Println("Error migrating database:", err)
+	}
 
 	parkingLotServer := server.NewServer(db)
+	// The tests stand for a gateway naming the tenant of each request.
+	parkingLotServer.Tenants.AllowHeader = true
+	// The test webhook receivers listen on the loopback address.
+	parkingLotServer.AllowPrivateWebhooks = true
 
 	routes.SetupRoutes(router, parkingLotServer)
 
-	return router
+	return router, parkingLotServer
+}
+
+// send serves a request with a JSON body and the given header name and value
+// pairs; headers with an empty value are left out.
+func send(router http.Handler, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	request, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		if headers[i+1] != "" {
+			request.Header.Set(headers[i], headers[i+1])
+		}
+	}
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	return response
 }
@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// HealthResponse represents the response structure of the health endpoints.
+type HealthResponse struct {
+	Status string `json:"status"`
+	Checks map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"checks"`
+}
+
+func TestHealthz(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the liveness endpoint.
+	request, err := http.NewRequest("GET", "/healthz", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Healthz - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestReadyz(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the readiness endpoint.
+	request, err := http.NewRequest("GET", "/readyz", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Readyz - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a HealthResponse.
+	var health HealthResponse
+	err = json.Unmarshal(response.Body.Bytes(), &health)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", health.Checks["database"].Status)
+	assert.Equal(t, "ok", health.Checks["migrations"].Status)
+}
+
+func TestReadyz2(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// Start draining the server as on SIGTERM.
+	parkingLotServer.BeginShutdown()
+
+	request, err := http.NewRequest("GET", "/readyz", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Readyz 2 - While shutting down\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Readyz 2 - HTTP Status Code: %d (Must be 503)\n", response.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	// Convert the response body into a HealthResponse.
+	var health HealthResponse
+	err = json.Unmarshal(response.Body.Bytes(), &health)
+	assert.NoError(t, err)
+	assert.Equal(t, "fail", health.Checks["shutdown"].Status)
+}
@@ -0,0 +1,90 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/idempotency"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentAddCar(t *testing.T) {
+
+	router := setupRouter()
+	body := `{"model": "Idem", "registration": "RegIdem", "category": "FDAR"}`
+
+	// Send the same request twice with the same key.
+	first := send(router, "POST", "/cars", body, idempotency.KeyHeader, "add-RegIdem")
+	second := send(router, "POST", "/cars", body, idempotency.KeyHeader, "add-RegIdem")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Idempotent Add Car - HTTP Status Codes: %d, %d (Must be 201, 201)\n", first.Code, second.Code)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+
+	// The second response is the stored copy of the first.
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, "true", second.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+}
+
+func TestIdempotencyKeyReuse(t *testing.T) {
+
+	router := setupRouter()
+
+	// Reuse the key of the previous test with a different body.
+	response := send(router, "POST", "/cars", `{"model": "Other", "registration": "RegOther"}`, idempotency.KeyHeader, "add-RegIdem")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Idempotency Key Reuse - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestIdempotentReturnCar(t *testing.T) {
+
+	router := setupRouter()
+
+	// Rent the car, then return it twice with the same key.
+	response := send(router, "PUT", "/cars/RegIdem/rentals", "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	first := send(router, "PUT", "/cars/RegIdem/returns", `{"odometer": 50}`, idempotency.KeyHeader, "return-RegIdem")
+	second := send(router, "PUT", "/cars/RegIdem/returns", `{"odometer": 50}`, idempotency.KeyHeader, "return-RegIdem")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Idempotent Return Car - HTTP Status Codes: %d, %d (Must be 200, 200)\n", first.Code, second.Code)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+
+	// Only one return reading was recorded.
+	response = send(router, "GET", "/cars/RegIdem/odometer", "")
+
+	var readings []model.OdometerReading
+	err := json.Unmarshal(response.Body.Bytes(), &readings)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Idempotent Return Car - Number of readings: %d (Must be 3)\n", len(readings))
+	assert.Len(t, readings, 3)
+}
+
+func TestIdempotencyKeyExpiry(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.Idempotency.TTL = -time.Second
+
+	// The stored response expires at once, so the retry runs again.
+	body := `{"model": "Idem", "registration": "RegIdem2", "category": "FDAR"}`
+	first := send(router, "POST", "/cars", body, idempotency.KeyHeader, "add-RegIdem2")
+	second := send(router, "POST", "/cars", body, idempotency.KeyHeader, "add-RegIdem2")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Idempotency Key Expiry - HTTP Status Codes: %d, %d (Must be 201, 409)\n", first.Code, second.Code)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusConflict, second.Code)
+	assert.Empty(t, second.Header().Get(idempotency.ReplayedHeader))
+}
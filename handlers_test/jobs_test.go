@@ -0,0 +1,146 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/handlers"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminToken is the admin token of the servers running jobs in the tests.
+const adminToken = "test-admin-token"
+
+func TestOverdueRentalsJob(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.AdminToken = adminToken
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Overdue", "registration": "JOB-100", "daily_rate": 40}`).Code)
+
+	code, rental := rentUntil(t, router, "JOB-100", 24*time.Hour)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Move the planned end 26 hours back: past the grace period, the two
+	// started late days are charged at 1.5 times the rate.
+	err := parkingLotServer.ParkingLotService.DB.Model(&rental).
+		Update("planned_end_at", time.Now().Add(-26*time.Hour)).Error
+	assert.NoError(t, err)
+
+	response := send(router, "POST", "/jobs/"+server.OverdueRentalsJob+"/runs", "", handlers.AdminTokenHeader, adminToken)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Overdue Rentals Job - Run Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var run model.JobRun
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &run))
+	assert.Equal(t, model.JobSucceeded, run.Status)
+	assert.Equal(t, "1 rentals flagged overdue, 0 late fees accrued", run.Summary)
+
+	flagged, err := parkingLotServer.ParkingLotService.GetRental(context.Background(), rental.ID)
+	assert.NoError(t, err)
+	assert.True(t, flagged.Overdue)
+	assert.Equal(t, 120.0, flagged.LateFee)
+
+	// The overdue event is written to the outbox once: a second run has
+	// nothing left to do.
+	var events int64
+	parkingLotServer.ParkingLotService.DB.Model(&model.OutboxEvent{}).Where("type = ?", model.EventRentalOverdue).Count(&events)
+	assert.Equal(t, int64(1), events)
+
+	response = send(router, "POST", "/jobs/"+server.OverdueRentalsJob+"/runs", "", handlers.AdminTokenHeader, adminToken)
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &run))
+	assert.Equal(t, "0 rentals flagged overdue, 0 late fees accrued", run.Summary)
+
+	response = send(router, "GET", "/jobs/runs?job="+server.OverdueRentalsJob, "", handlers.AdminTokenHeader, adminToken)
+
+	fmt.Printf("Test Overdue Rentals Job - History Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var runs []model.JobRun
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &runs))
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, run.ID, runs[0].ID)
+	}
+
+	response = send(router, "GET", "/jobs", "", handlers.AdminTokenHeader, adminToken)
+	var jobs []handlers.JobResponse
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &jobs))
+	for _, job := range jobs {
+		if job.Name == server.OverdueRentalsJob && assert.NotNil(t, job.LastRun) {
+			assert.Equal(t, run.ID, job.LastRun.ID)
+		}
+	}
+}
+
+func TestJobLock(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.AdminToken = adminToken
+
+	// Another replica holds the lock: the job is not run twice.
+	lock := model.JobLock{Job: server.OverdueRentalsJob, Owner: "other-replica", ExpiresAt: time.Now().Add(time.Minute)}
+	assert.NoError(t, parkingLotServer.ParkingLotService.DB.Create(&lock).Error)
+
+	response := send(router, "POST", "/jobs/"+server.OverdueRentalsJob+"/runs", "", handlers.AdminTokenHeader, adminToken)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Job Lock - Locked Run Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	// Once expired, the lock of a replica that stopped is taken over.
+	err := parkingLotServer.ParkingLotService.DB.Model(&lock).Update("expires_at", time.Now().Add(-time.Second)).Error
+	assert.NoError(t, err)
+
+	response = send(router, "POST", "/jobs/"+server.OverdueRentalsJob+"/runs", "", handlers.AdminTokenHeader, adminToken)
+
+	fmt.Printf("Test Job Lock - Expired Lock Run Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var run model.JobRun
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &run))
+
+	// The scheduler skips the job now that it ran within its interval.
+	assert.NoError(t, parkingLotServer.Jobs.RunDue(context.Background()))
+	last, err := parkingLotServer.Jobs.LastRun(context.Background(), server.OverdueRentalsJob)
+	assert.NoError(t, err)
+	if assert.NotNil(t, last) {
+		assert.Equal(t, run.ID, last.ID)
+	}
+}
+
+func TestJobsRequireAdmin(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// Without an admin token configured, nobody reaches the jobs.
+	response := send(router, "GET", "/jobs/runs", "", tenant.Header, "franchise-a")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Jobs Require Admin - Disabled Status Code: %d (Must be 403)\n", response.Code)
+	assert.Equal(t, http.StatusForbidden, response.Code)
+
+	// Tenants cannot list or run them without the token either.
+	parkingLotServer.AdminToken = adminToken
+	for _, token := range []string{"", "wrong-token"} {
+		response = send(router, "GET", "/jobs/runs", "", tenant.Header, "franchise-a", handlers.AdminTokenHeader, token)
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+		assert.NotContains(t, response.Body.String(), server.OverdueRentalsJob)
+
+		response = send(router, "POST", "/jobs/"+server.OverdueRentalsJob+"/runs", "", handlers.AdminTokenHeader, token)
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+	}
+
+	response = send(router, "GET", "/jobs", "", handlers.AdminTokenHeader, adminToken)
+
+	fmt.Printf("Test Jobs Require Admin - Admin Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
@@ -38,3 +38,49 @@ func TestListCars(t *testing.T) {
 	fmt.Printf("Test List Cars - Number of Cars in the Response: %d (Must be >= 3)\n", len(cars))
 	assert.True(t, len(cars) >= 3)
 }
+
+func TestListCars2(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request searching for hybrid Toyotas from 2020 onwards.
+	request, err := http.NewRequest("GET", "/cars?make=Toyota&fuel_type=hybrid&min_year=2020", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test List Cars 2 - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a slice of model.Car.
+	var cars []model.Car
+	err = json.Unmarshal(response.Body.Bytes(), &cars)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test List Cars 2 - Number of Cars in the Response: %d (Must be 1)\n", len(cars))
+	assert.Len(t, cars, 1)
+}
+
+func TestListCars3(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request with a malformed year filter.
+	request, err := http.NewRequest("GET", "/cars?min_year=recent", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test List Cars 3 - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
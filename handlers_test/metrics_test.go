@@ -0,0 +1,51 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/handlers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.AdminToken = adminToken
+
+	// Serve a first request so the HTTP metrics have a sample.
+	request, err := http.NewRequest("GET", "/cars/Reg1", nil)
+	assert.NoError(t, err)
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// The metrics cover every tenant: they require the admin token.
+	response := send(router, "GET", "/metrics", "")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Metrics - Without Admin Token Status Code: %d (Must be 401)\n", response.Code)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	// Create a GET request for the "/metrics" endpoint.
+	request, err = http.NewRequest("GET", "/metrics", nil)
+	assert.NoError(t, err)
+	request.Header.Set(handlers.AdminTokenHeader, adminToken)
+
+	// Record the response.
+	response = httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Metrics - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// The request is labelled with its route template, not its path.
+	body := response.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/cars/{registration}",status="200"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/cars/{registration}"`)
+	assert.Contains(t, body, `fleet_cars{status="available"}`)
+	assert.Contains(t, body, "fleet_rentals_opened_total")
+	assert.Contains(t, body, "go_sql_open_connections")
+}
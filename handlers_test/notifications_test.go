@@ -0,0 +1,150 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingNotifier refuses every message.
+type failingNotifier struct{}
+
+func (failingNotifier) Channel() model.NotificationChannel { return model.ChannelSMS }
+
+func (failingNotifier) Send(ctx context.Context, message notify.Message) error {
+	return errors.New("gateway unavailable")
+}
+
+// enableNotifications sends the email notifications of the server to out and
+// the text messages to notifier.
+func enableNotifications(s *server.Server, out *bytes.Buffer, notifier notify.Notifier) {
+	s.ParkingLotService.Notifications.Channels = []model.NotificationChannel{model.ChannelEmail, model.ChannelSMS}
+	s.Notifications.Notifiers[model.ChannelEmail] = notify.NewSink(model.ChannelEmail, out)
+	s.Notifications.Notifiers[model.ChannelSMS] = notifier
+}
+
+// addCustomer registers a customer reachable by email and text message.
+func addCustomer(t *testing.T, s *server.Server, name, locale string) model.Customer {
+	born := time.Now().AddDate(-40, 0, 0)
+	issued := time.Now().AddDate(-20, 0, 0)
+	customer := model.Customer{
+		Name:            name,
+		Email:           name + "@example.com",
+		Phone:           "+33600000000",
+		Locale:          locale,
+		DateOfBirth:     &born,
+		LicenceIssuedAt: &issued,
+	}
+
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	assert.NoError(t, s.ParkingLotService.CreateCustomer(ctx, &customer))
+	return customer
+}
+
+// notificationsOf lists the notifications of a customer through the API.
+func notificationsOf(t *testing.T, router http.Handler, customer model.Customer) []model.Notification {
+	response := send(router, "GET", fmt.Sprintf("/notifications?customer_id=%d", customer.ID), "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var notifications []model.Notification
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &notifications))
+	return notifications
+}
+
+func TestRentalNotifications(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	var out bytes.Buffer
+	enableNotifications(parkingLotServer, &out, notify.NewSink(model.ChannelSMS, &out))
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Notified", "registration": "NOT-100", "daily_rate": 40}`).Code)
+	customer := addCustomer(t, parkingLotServer, "camille", "fr-FR")
+
+	response := rentFor(router, "NOT-100", customer.ID)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// The confirmation is queued on both channels, in French.
+	notifications := notificationsOf(t, router, customer)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rental Notifications - Queued After Rent: %d (Must be 2)\n", len(notifications))
+	if assert.Len(t, notifications, 2) {
+		for _, notification := range notifications {
+			assert.Equal(t, model.NotifyBookingConfirmation, notification.Kind)
+			assert.Equal(t, model.NotificationPending, notification.Status)
+			assert.Equal(t, "fr", notification.Locale)
+		}
+	}
+
+	assert.NoError(t, parkingLotServer.Notifications.RunOnce(context.Background()))
+	assert.Contains(t, out.String(), "Votre location de la Notified a commencé")
+
+	response = send(router, "PUT", "/cars/NOT-100/returns", `{"odometer": 120}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NoError(t, parkingLotServer.Notifications.RunOnce(context.Background()))
+
+	// The invoice follows the return; every notification has been sent.
+	notifications = notificationsOf(t, router, customer)
+
+	fmt.Printf("Test Rental Notifications - Sent After Return: %d (Must be 4)\n", len(notifications))
+	if assert.Len(t, notifications, 4) {
+		assert.Equal(t, model.NotifyInvoice, notifications[0].Kind)
+		for _, notification := range notifications {
+			assert.Equal(t, model.NotificationSent, notification.Status)
+		}
+	}
+	assert.Contains(t, out.String(), "Votre facture pour la Notified")
+}
+
+func TestNotificationRetry(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	var out bytes.Buffer
+	enableNotifications(parkingLotServer, &out, failingNotifier{})
+	parkingLotServer.Notifications.MaxAttempts = 1
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Notified", "registration": "NOT-200"}`).Code)
+	customer := addCustomer(t, parkingLotServer, "alex", "")
+
+	response := rentFor(router, "NOT-200", customer.ID)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NoError(t, parkingLotServer.Notifications.RunOnce(context.Background()))
+
+	// The email went out; the text message exhausted its single attempt.
+	response = send(router, "GET", fmt.Sprintf("/notifications?customer_id=%d&status=dead", customer.ID), "")
+
+	var dead []model.Notification
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &dead))
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Notification Retry - Dead Notifications: %d (Must be 1)\n", len(dead))
+	if !assert.Len(t, dead, 1) {
+		return
+	}
+	assert.Equal(t, model.ChannelSMS, dead[0].Channel)
+	assert.Equal(t, "gateway unavailable", dead[0].LastError)
+	assert.Contains(t, out.String(), "Your rental of the Notified has started")
+
+	// Once the gateway is back, the notification is sent again on request.
+	parkingLotServer.Notifications.Notifiers[model.ChannelSMS] = notify.NewSink(model.ChannelSMS, &out)
+	response = send(router, "POST", fmt.Sprintf("/notifications/%d/retry", dead[0].ID), "")
+
+	fmt.Printf("Test Notification Retry - Retry Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.NoError(t, parkingLotServer.Notifications.RunOnce(context.Background()))
+	for _, notification := range notificationsOf(t, router, customer) {
+		assert.Equal(t, model.NotificationSent, notification.Status)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/openapi"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadSpec parses and validates the embedded OpenAPI document.
+func loadSpec(t *testing.T) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(openapi.Spec)
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+
+	router := setupRouter()
+	doc := loadSpec(t)
+
+	fmt.Printf("\n------\n")
+
+	// Walk the registered routes and look each of them up in the document.
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		pathItem := doc.Paths.Find(template)
+		if !assert.NotNil(t, pathItem, "route %s is missing from the OpenAPI document", template) {
+			return nil
+		}
+		for _, method := range methods {
+			assert.NotNil(t, pathItem.GetOperation(method), "operation %s %s is missing from the OpenAPI document", method, template)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	fmt.Printf("Test OpenAPI Routes - Every route is documented\n")
+}
+
+func TestOpenAPIResponses(t *testing.T) {
+
+	router := setupRouter()
+	doc := loadSpec(t)
+
+	// Each request is served and its response checked against the document.
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/cars", ""},
+		{"GET", "/cars?available=true", ""},
+		{"GET", "/cars?min_year=recent", ""},
+		{"GET", "/cars/search?q=reg-1", ""},
+		{"GET", "/cars/search", ""},
+		{"POST", "/cars", `{"model": "Clio"}`},
+		{"GET", "/cars/Reg1", ""},
+		{"GET", "/cars/RegXXX", ""},
+		{"GET", "/cars/Reg1/transitions", ""},
+		{"GET", "/cars/Reg1/odometer", ""},
+		{"PUT", "/cars/Reg3/rentals", ""},
+		{"PUT", "/cars/Reg1/returns", `{"odometer": 600}`},
+		{"PUT", "/cars/Reg1/status", `{"status": "rented"}`},
+		{"GET", "/rentals/reviews", ""},
+		{"GET", "/rentals/999999/payments", ""},
+		{"POST", "/rentals/999999/refunds", `{"amount": 10}`},
+		{"POST", "/rentals/999999/extend", `{"planned_end_at": "2030-01-01T00:00:00Z"}`},
+		{"GET", "/cars/Reg1/reservations", ""},
+		{"POST", "/cars/Reg1/reservations", `{"starts_at": "2030-01-02T00:00:00Z", "ends_at": "2030-01-01T00:00:00Z"}`},
+		{"DELETE", "/reservations/999999", ""},
+		{"GET", "/reports/utilization?group_by=model", ""},
+		{"GET", "/reports/rentals", ""},
+		{"GET", "/reports/idle?days=0", ""},
+		{"GET", "/reports/revenue", ""},
+		{"GET", "/healthz", ""},
+		{"GET", "/readyz", ""},
+		{"POST", "/graphql", `{"query": "{ car(registration: \"Reg1\") { model currentRental { id } } }"}`},
+		{"GET", "/webhooks", ""},
+		{"POST", "/webhooks", `{"url": "ftp://example.com", "events": ["*"]}`},
+		{"GET", "/webhooks/deliveries?status=dead", ""},
+		{"GET", "/blocklist", ""},
+		{"POST", "/blocklist", `{"reason": "no licence"}`},
+		{"DELETE", "/blocklist/999999", ""},
+		{"GET", "/notifications?status=lost", ""},
+		{"POST", "/notifications/999999/retry", ""},
+		{"GET", "/jobs", ""},
+		{"GET", "/jobs/runs?status=lost", ""},
+		{"POST", "/jobs/unknown/runs", ""},
+	}
+
+	fmt.Printf("\n")
+
+	for _, req := range requests {
+		request, err := http.NewRequest(req.method, req.path, strings.NewReader(req.body))
+		assert.NoError(t, err)
+
+		// Find the route template the request matches.
+		var match mux.RouteMatch
+		if !assert.True(t, router.Match(request, &match), "%s %s matches no route", req.method, req.path) {
+			continue
+		}
+		template, _ := match.Route.GetPathTemplate()
+		pathItem := doc.Paths.Find(template)
+
+		// Record the response.
+		response := httptest.NewRecorder()
+
+		// Serve the HTTP request.
+		router.ServeHTTP(response, request)
+
+		input := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: match.Vars,
+				Route: &routers.Route{
+					Spec:      doc,
+					Path:      template,
+					PathItem:  pathItem,
+					Method:    req.method,
+					Operation: pathItem.GetOperation(req.method),
+				},
+			},
+			Status:  response.Code,
+			Header:  response.Header(),
+			Options: &openapi3filter.Options{IncludeResponseStatus: true},
+		}
+		input.SetBodyBytes(bytes.Clone(response.Body.Bytes()))
+
+		err = openapi3filter.ValidateResponse(context.Background(), input)
+		fmt.Printf("Test OpenAPI Responses - %s %s: %d\n", req.method, req.path, response.Code)
+		assert.NoError(t, err, "%s %s", req.method, req.path)
+	}
+}
@@ -0,0 +1,206 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupPayments returns a router whose rentals hold a deposit of 300 with a
+// fake provider.
+func setupPayments() (*mux.Router, *server.Server, *payment.Fake) {
+	router, parkingLotServer := setupServer()
+	provider := payment.NewFake()
+	parkingLotServer.ParkingLotService.Payments = provider
+	parkingLotServer.ParkingLotService.Deposit = 300
+	return router, parkingLotServer, provider
+}
+
+func TestRentDepositDeclined(t *testing.T) {
+
+	router, _, provider := setupPayments()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Deposit", "registration": "PAY-100", "daily_rate": 50}`).Code)
+
+	// Without a payment method there is nothing to hold the deposit on.
+	response := send(router, "PUT", "/cars/PAY-100/rentals", "")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rent Deposit Declined - No Payment Method Status Code: %d (Must be 402)\n", response.Code)
+	assert.Equal(t, http.StatusPaymentRequired, response.Code)
+
+	response = send(router, "PUT", "/cars/PAY-100/rentals", `{"payment_method": "`+payment.DeclinedMethod+`"}`)
+
+	fmt.Printf("Test Rent Deposit Declined - HTTP Status Code: %d (Must be 402)\n", response.Code)
+	assert.Equal(t, http.StatusPaymentRequired, response.Code)
+
+	// The car was not rented and nothing is held.
+	response = send(router, "GET", "/cars/PAY-100", "")
+	var car model.Car
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, model.StatusAvailable, car.Status)
+	assert.Zero(t, provider.Held())
+}
+
+func TestRentDepositReleased(t *testing.T) {
+
+	router, _, provider := setupPayments()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Deposit", "registration": "PAY-150", "daily_rate": 50}`).Code)
+	assert.Equal(t, http.StatusOK, send(router, "PUT", "/cars/PAY-150/rentals", `{"payment_method": "tok_visa"}`).Code)
+	assert.Equal(t, 300.0, provider.Held())
+
+	// The deposit of a rental that cannot be opened is released.
+	response := send(router, "PUT", "/cars/PAY-150/rentals", `{"payment_method": "tok_visa"}`)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rent Deposit Released - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+	assert.Equal(t, 300.0, provider.Held())
+}
+
+func TestDepositCaptureAndRefund(t *testing.T) {
+
+	router, _, provider := setupPayments()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Deposit", "registration": "PAY-200", "daily_rate": 50}`).Code)
+
+	response := send(router, "PUT", "/cars/PAY-200/rentals", `{"payment_method": "tok_visa"}`)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Deposit Capture - Rent Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var rented struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rented))
+	assert.Equal(t, 300.0, rented.Rental.Deposit)
+	assert.Equal(t, 300.0, provider.Held())
+
+	// A one day rental costs 50: it is captured and the rest released.
+	response = send(router, "PUT", "/cars/PAY-200/returns", `{"odometer": 120}`)
+
+	fmt.Printf("Test Deposit Capture - Return Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Zero(t, provider.Held())
+
+	paymentsPath := fmt.Sprintf("/rentals/%d/payments", rented.Rental.ID)
+	response = send(router, "GET", paymentsPath, "")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var payments []model.Payment
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &payments))
+	if assert.Len(t, payments, 3) {
+		assert.Equal(t, model.PaymentAuthorization, payments[0].Kind)
+		assert.Equal(t, model.PaymentCapture, payments[1].Kind)
+		assert.Equal(t, 50.0, payments[1].Amount)
+		assert.Equal(t, model.PaymentRelease, payments[2].Kind)
+		assert.Equal(t, 250.0, payments[2].Amount)
+	}
+
+	// Refunds are limited to the captured amount.
+	refundsPath := fmt.Sprintf("/rentals/%d/refunds", rented.Rental.ID)
+	response = send(router, "POST", refundsPath, `{"amount": 20, "reason": "late pickup"}`)
+
+	fmt.Printf("Test Deposit Capture - Refund Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	response = send(router, "POST", refundsPath, `{"amount": 40}`)
+
+	fmt.Printf("Test Deposit Capture - Excess Refund Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestDepositCaptureFailure(t *testing.T) {
+
+	router, s, provider := setupPayments()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Deposit", "registration": "PAY-300", "daily_rate": 50}`).Code)
+	response := send(router, "PUT", "/cars/PAY-300/rentals", `{"payment_method": "tok_visa"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var rented struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rented))
+
+	// The provider cannot be reached: the car is back, the deposit still held.
+	provider.CaptureFailures = 1
+	response = send(router, "PUT", "/cars/PAY-300/returns", `{"odometer": 80}`)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Deposit Capture Failure - Return Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 300.0, provider.Held())
+
+	paymentsPath := fmt.Sprintf("/rentals/%d/payments", rented.Rental.ID)
+	var payments []model.Payment
+	assert.NoError(t, json.Unmarshal(send(router, "GET", paymentsPath, "").Body.Bytes(), &payments))
+	if assert.Len(t, payments, 2) {
+		assert.Equal(t, model.PaymentPending, payments[1].Status)
+		assert.Equal(t, 1, payments[1].Attempts)
+		assert.NotEmpty(t, payments[1].Error)
+	}
+
+	// The next retry captures the amount and releases the rest.
+	db := s.ParkingLotService.DB
+	assert.NoError(t, db.Model(&model.Payment{}).Where("rental_id = ? AND kind = ?", rented.Rental.ID, model.PaymentCapture).Update("next_attempt_at", time.Now()).Error)
+	captured, err := s.ParkingLotService.CaptureDeposits(context.Background())
+
+	fmt.Printf("Test Deposit Capture Failure - Captured: %d (Must be 1)\n", captured)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, captured)
+	assert.Zero(t, provider.Held())
+
+	assert.NoError(t, json.Unmarshal(send(router, "GET", paymentsPath, "").Body.Bytes(), &payments))
+	if assert.Len(t, payments, 3) {
+		assert.Equal(t, model.PaymentSucceeded, payments[1].Status)
+		assert.Equal(t, 2, payments[1].Attempts)
+		assert.Equal(t, model.PaymentRelease, payments[2].Kind)
+	}
+}
+
+func TestDepositCaptureGivenUp(t *testing.T) {
+
+	router, s, provider := setupPayments()
+	s.ParkingLotService.CaptureAttempts = 1
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Deposit", "registration": "PAY-400", "daily_rate": 50}`).Code)
+	response := send(router, "PUT", "/cars/PAY-400/rentals", `{"payment_method": "tok_visa"}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var rented struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rented))
+
+	// Out of attempts, the deposit is released and the amount is due.
+	provider.CaptureFailures = 1
+	response = send(router, "PUT", "/cars/PAY-400/returns", `{"odometer": 80}`)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Deposit Capture Given Up - Held: %.0f (Must be 0)\n", provider.Held())
+	assert.Zero(t, provider.Held())
+
+	var payments []model.Payment
+	response = send(router, "GET", fmt.Sprintf("/rentals/%d/payments", rented.Rental.ID), "")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &payments))
+	if assert.Len(t, payments, 4) {
+		assert.Equal(t, model.PaymentFailed, payments[1].Status)
+		assert.Equal(t, model.PaymentRelease, payments[2].Kind)
+		assert.Equal(t, 300.0, payments[2].Amount)
+		assert.Equal(t, model.PaymentBalance, payments[3].Kind)
+		assert.Equal(t, 50.0, payments[3].Amount)
+	}
+}
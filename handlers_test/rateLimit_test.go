@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+
+	router, s := setupServer()
+	// Clients are told apart by the X-Forwarded-For address.
+	s.RateLimit.TrustProxy = true
+	s.RateLimit.Routes["GET /cars/{registration}"] = ratelimit.Limit{Burst: 2, Period: time.Minute}
+
+	// The burst is served, then the client is refused.
+	first := send(router, "GET", "/cars/Reg1", "", "X-Forwarded-For", "203.0.113.7")
+	second := send(router, "GET", "/cars/Reg3", "", "X-Forwarded-For", "203.0.113.7")
+	third := send(router, "GET", "/cars/Reg1", "", "X-Forwarded-For", "203.0.113.7")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rate Limit - HTTP Status Codes: %d, %d, %d (Must be 200, 200, 429)\n", first.Code, second.Code, third.Code)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "0", second.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", second.Header().Get("RateLimit-Reset"))
+
+	fmt.Printf("Test Rate Limit - Retry-After: %s (Must be 30)\n", third.Header().Get("Retry-After"))
+	assert.Equal(t, "30", third.Header().Get("Retry-After"))
+	assert.Contains(t, third.Body.String(), "Rate limit exceeded")
+
+	// Other clients, and other routes, have their own buckets.
+	other := send(router, "GET", "/cars/Reg1", "", "X-Forwarded-For", "203.0.113.8")
+	assert.Equal(t, http.StatusOK, other.Code)
+
+	list := send(router, "GET", "/cars", "", "X-Forwarded-For", "203.0.113.7")
+	assert.Equal(t, http.StatusOK, list.Code)
+	assert.Empty(t, list.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitAPIKey(t *testing.T) {
+
+	router, s := setupServer()
+	// Clients are told apart by the X-Forwarded-For address.
+	s.RateLimit.TrustProxy = true
+	s.RateLimit.Default = ratelimit.Limit{Burst: 1, Period: time.Hour}
+	s.RateLimit.Routes["GET /healthz"] = ratelimit.Limit{}
+	s.RateLimit.APIKeys = ratelimit.ParseAPIKeys("integration-a, integration-b")
+
+	// Requests with a known API key are limited by key, whatever their address.
+	first := send(router, "GET", "/cars", "", "X-Forwarded-For", "198.51.100.1", ratelimit.APIKeyHeader, "integration-a")
+	second := send(router, "GET", "/cars", "", "X-Forwarded-For", "198.51.100.2", ratelimit.APIKeyHeader, "integration-a")
+	third := send(router, "GET", "/cars", "", "X-Forwarded-For", "198.51.100.1", ratelimit.APIKeyHeader, "integration-b")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rate Limit API Key - HTTP Status Codes: %d, %d, %d (Must be 200, 429, 200)\n", first.Code, second.Code, third.Code)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Equal(t, http.StatusOK, third.Code)
+
+	// Routes turned off are never limited.
+	for i := 0; i < 3; i++ {
+		response := send(router, "GET", "/healthz", "", "X-Forwarded-For", "198.51.100.1", ratelimit.APIKeyHeader, "integration-a")
+		assert.Equal(t, http.StatusOK, response.Code)
+	}
+}
+
+func TestRateLimitUnknownAPIKeys(t *testing.T) {
+
+	router, s := setupServer()
+	// Clients are told apart by the X-Forwarded-For address.
+	s.RateLimit.TrustProxy = true
+	s.RateLimit.Default = ratelimit.Limit{Burst: 1, Period: time.Hour}
+	s.RateLimit.APIKeys = ratelimit.ParseAPIKeys("integration-a")
+
+	// Rotating unknown keys does not give a client a new bucket.
+	first := send(router, "GET", "/cars", "", "X-Forwarded-For", "198.51.100.9", ratelimit.APIKeyHeader, "rotated-1")
+	second := send(router, "GET", "/cars", "", "X-Forwarded-For", "198.51.100.9", ratelimit.APIKeyHeader, "rotated-2")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rate Limit Unknown API Keys - HTTP Status Codes: %d, %d (Must be 200, 429)\n", first.Code, second.Code)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+}
+
+func TestParseRouteLimits(t *testing.T) {
+
+	limits, err := ratelimit.ParseRouteLimits("POST /cars=30/m, get /cars=10/s,GET /healthz=off,GET /reports/rentals=100/1h")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Parse Route Limits - Routes: %d (Must be 4)\n", len(limits))
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Burst: 30, Period: time.Minute}, limits["POST /cars"])
+	assert.Equal(t, ratelimit.Limit{Burst: 10, Period: time.Second}, limits["GET /cars"])
+	assert.True(t, limits["GET /healthz"].Unlimited())
+	assert.Equal(t, ratelimit.Limit{Burst: 100, Period: time.Hour}, limits["GET /reports/rentals"])
+
+	for _, invalid := range []string{"POST /cars", "/cars=10/m", "POST /cars=ten/m", "POST /cars=10/week", "POST /cars=0/m"} {
+		_, err := ratelimit.ParseRouteLimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCanonicalRegistration(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "POST", "/cars", `{"model": "Plate", "registration": " NR-451  XY "}`)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Canonical Registration - HTTP Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// The display form keeps the separators, the canonical form drops them.
+	var car model.Car
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, "NR-451 XY", car.Registration)
+	assert.Equal(t, "NR451XY", car.CanonicalRegistration)
+
+	// The car is found under any spelling of its registration.
+	request, err := http.NewRequest("GET", "/cars/nr%20451-xy", nil)
+	assert.NoError(t, err)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Canonical Registration - Lookup Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, "NR-451 XY", car.Registration)
+}
+
+func TestDuplicateRegistrationSpellings(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "POST", "/cars", `{"model": "Plate", "registration": "KM-802-TE"}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// Other case and separators, and Cyrillic look-alikes of K, M, T and E.
+	for _, registration := range []string{"km802te", "KM 802 TE", "КМ-802-ТЕ"} {
+		response := send(router, "POST", "/cars", `{"model": "Plate", "registration": "`+registration+`"}`)
+
+		fmt.Printf("Test Duplicate Registration Spellings - %s: %d (Must be 409)\n", registration, response.Code)
+		assert.Equal(t, http.StatusConflict, response.Code)
+	}
+}
+
+func TestRegistrationWithoutCharacters(t *testing.T) {
+
+	router := setupRouter()
+
+	response := send(router, "POST", "/cars", `{"model": "Plate", "registration": "- -"}`)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Registration Without Characters - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+// baselineCar is a car as stored before categories, statuses, tenants and
+// canonical registrations were added.
+type baselineCar struct {
+	gorm.Model
+	CarModel     string  `json:"model"`
+	Registration string  `json:"registration" gorm:"unique;not null"`
+	Mileage      float64 `json:"mileage"`
+	Available    bool    `json:"available"`
+}
+
+func (baselineCar) TableName() string { return "cars" }
+
+func TestMigrateBaselineCars(t *testing.T) {
+
+	// Migrate a database of its own, created with the baseline cars table.
+	root, err := service.InitializeDB("root:@tcp(127.0.0.1:3306)/?charset=utf8&parseTime=True&loc=Local")
+	assert.NoError(t, err)
+	assert.NoError(t, root.Exec("DROP DATABASE IF EXISTS parking_lot_migrate_test").Error)
+	assert.NoError(t, root.Exec("CREATE DATABASE parking_lot_migrate_test").Error)
+	defer root.Exec("DROP DATABASE parking_lot_migrate_test")
+
+	db, err := service.InitializeDB("root:@tcp(127.0.0.1:3306)/parking_lot_migrate_test?charset=utf8&parseTime=True&loc=Local")
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&baselineCar{}))
+	assert.NoError(t, db.Create(&[]baselineCar{
+		{CarModel: "Old", Registration: "ab-123", Mileage: 100, Available: true},
+		{CarModel: "Old", Registration: "CD 456", Mileage: 200, Available: false},
+	}).Error)
+
+	err = service.MigrateDB(db)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Migrate Baseline Cars - Error: %v (Must be nil)\n", err)
+	assert.NoError(t, err)
+
+	// The existing cars belong to the default tenant and keep their state.
+	var cars []model.Car
+	assert.NoError(t, db.Order("id").Find(&cars).Error)
+	if assert.Len(t, cars, 2) {
+		assert.Equal(t, "default", cars[0].TenantID)
+		assert.Equal(t, "AB123", cars[0].CanonicalRegistration)
+		assert.Equal(t, model.StatusAvailable, cars[0].Status)
+		assert.Equal(t, "default", cars[1].TenantID)
+		assert.Equal(t, "CD456", cars[1].CanonicalRegistration)
+		assert.Equal(t, model.StatusRented, cars[1].Status)
+	}
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+}
@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRentByCategory(t *testing.T) {
+
+	router := setupRouter()
+
+	// Ask for any compact automatic, letting the lowest mileage car win.
+	payload := map[string]string{
+		"category": "C*A*",
+		"strategy": "lowest_mileage",
+	}
+
+	// Convert the payload to JSON.
+	payloadBytes, err := json.Marshal(payload)
+	assert.NoError(t, err)
+
+	// Create a POST request to rent a car by category.
+	request, err := http.NewRequest("POST", "/rentals", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rent By Category - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a CarResponse.
+	var rentCarResponse CarResponse
+	err = json.Unmarshal(response.Body.Bytes(), &rentCarResponse)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Rent By Category - Registration: %s (Must be Reg4)\n", rentCarResponse.Car.Registration)
+	assert.Equal(t, "Reg4", rentCarResponse.Car.Registration)
+	assert.False(t, rentCarResponse.Car.Available)
+}
+
+func TestRentByCategory2(t *testing.T) {
+
+	router := setupRouter()
+
+	// Ask for a category code that is not valid ACRISS.
+	payload := map[string]string{
+		"category": "ZZZZ",
+	}
+
+	// Convert the payload to JSON.
+	payloadBytes, err := json.Marshal(payload)
+	assert.NoError(t, err)
+
+	// Create a POST request to rent a car by category.
+	request, err := http.NewRequest("POST", "/rentals", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rent By Category 2 - With invalid category\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Rent By Category 2 - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestRentByCategory3(t *testing.T) {
+
+	router := setupRouter()
+
+	// Ask for a valid category that no car in the fleet belongs to.
+	payload := map[string]string{
+		"category": "LFAR",
+	}
+
+	// Convert the payload to JSON.
+	payloadBytes, err := json.Marshal(payload)
+	assert.NoError(t, err)
+
+	// Create a POST request to rent a car by category.
+	request, err := http.NewRequest("POST", "/rentals", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rent By Category 3 - With no car available\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Rent By Category 3 - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
@@ -0,0 +1,83 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUtilizationReport(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the utilization per car over the last 30 days.
+	request, err := http.NewRequest("GET", "/reports/utilization", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Utilization Report - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a slice of rows.
+	var rows []map[string]interface{}
+	err = json.Unmarshal(response.Body.Bytes(), &rows)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Utilization Report - Number of Cars in the Response: %d (Must be >= 3)\n", len(rows))
+	assert.True(t, len(rows) >= 3)
+}
+
+func TestRevenueReport(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the revenue per car exported as CSV.
+	request, err := http.NewRequest("GET", "/reports/revenue?from=2020-01-01&format=csv", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Revenue Report - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/csv", response.Header().Get("Content-Type"))
+
+	// Parse the CSV export and check its header line.
+	records, err := csv.NewReader(response.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"registration", "model", "rentals", "kilometers", "revenue"}, records[0])
+}
+
+func TestRentalReport(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request with a range that ends before it starts.
+	request, err := http.NewRequest("GET", "/reports/rentals?from=2024-02-01&to=2024-01-01", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Rental Report - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,49 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request carrying the request ID of an upstream proxy.
+	request, err := http.NewRequest("GET", "/cars/Reg1", nil)
+	assert.NoError(t, err)
+	request.Header.Set("X-Request-ID", "upstream-id-42")
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Request ID - X-Request-ID: %s (Must be upstream-id-42)\n", response.Header().Get("X-Request-ID"))
+	assert.Equal(t, "upstream-id-42", response.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID2(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request without a request ID.
+	request, err := http.NewRequest("GET", "/cars/Reg1", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Request ID 2 - X-Request-ID: %s (Must be generated)\n", response.Header().Get("X-Request-ID"))
+	assert.Len(t, response.Header().Get("X-Request-ID"), 32)
+}
@@ -0,0 +1,145 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// at formats the time d from now for a JSON payload.
+func at(d time.Duration) string {
+	return time.Now().Add(d).UTC().Format(time.RFC3339)
+}
+
+// rentUntil rents the car until the planned end and returns its rental.
+func rentUntil(t *testing.T, router http.Handler, registration string, plannedEnd time.Duration) (int, model.Rental) {
+	response := send(router, "PUT", "/cars/"+registration+"/rentals", `{"planned_end_at": "`+at(plannedEnd)+`"}`)
+
+	var rented struct {
+		Rental model.Rental `json:"rental"`
+	}
+	json.Unmarshal(response.Body.Bytes(), &rented)
+	return response.Code, rented.Rental
+}
+
+func TestRentReservedCar(t *testing.T) {
+
+	router := setupRouter()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Reserved", "registration": "RES-100", "daily_rate": 40}`).Code)
+
+	response := send(router, "POST", "/cars/RES-100/reservations", `{"starts_at": "`+at(time.Hour)+`", "ends_at": "`+at(5*time.Hour)+`"}`)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Rent Reserved Car - Reserve Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// A rental planned past the start of the reservation is refused...
+	code, _ := rentUntil(t, router, "RES-100", 3*time.Hour)
+
+	fmt.Printf("Test Rent Reserved Car - Overlapping Rent Status Code: %d (Must be 409)\n", code)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// ...but the car can go out until then.
+	code, rental := rentUntil(t, router, "RES-100", 30*time.Minute)
+
+	fmt.Printf("Test Rent Reserved Car - Short Rent Status Code: %d (Must be 200)\n", code)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 40.0, rental.PlannedAmount)
+}
+
+func TestReserveOpenRental(t *testing.T) {
+
+	router := setupRouter()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Open", "registration": "RES-150", "daily_rate": 40}`).Code)
+	assert.Equal(t, http.StatusOK, send(router, "PUT", "/cars/RES-150/rentals", "").Code)
+
+	// A rental without a planned end blocks the car until it is returned.
+	response := send(router, "POST", "/cars/RES-150/reservations", `{"starts_at": "`+at(24*time.Hour)+`", "ends_at": "`+at(48*time.Hour)+`"}`)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Reserve Open Rental - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestExtendRental(t *testing.T) {
+
+	router := setupRouter()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Extended", "registration": "RES-200", "daily_rate": 40}`).Code)
+
+	code, rental := rentUntil(t, router, "RES-200", 47*time.Hour)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 80.0, rental.PlannedAmount)
+
+	response := send(router, "POST", "/cars/RES-200/reservations", `{"starts_at": "`+at(72*time.Hour)+`", "ends_at": "`+at(96*time.Hour)+`"}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// The extension may not run into the reservation.
+	extendPath := fmt.Sprintf("/rentals/%d/extend", rental.ID)
+	response = send(router, "POST", extendPath, `{"planned_end_at": "`+at(80*time.Hour)+`"}`)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Extend Rental - Overlapping Extension Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	response = send(router, "POST", extendPath, `{"planned_end_at": "`+at(70*time.Hour)+`"}`)
+
+	fmt.Printf("Test Extend Rental - Extension Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rental))
+	assert.Equal(t, 120.0, rental.PlannedAmount)
+
+	// Returned the same day, the car is charged one day instead of three.
+	response = send(router, "PUT", "/cars/RES-200/returns", `{"odometer": 50}`)
+
+	fmt.Printf("Test Extend Rental - Early Return Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var returned struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &returned))
+	assert.Equal(t, 40.0, returned.Rental.Amount)
+	assert.Equal(t, -80.0, returned.Rental.Adjustment)
+}
+
+func TestLateReturn(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Late", "registration": "RES-300", "daily_rate": 40}`).Code)
+
+	code, rental := rentUntil(t, router, "RES-300", 24*time.Hour)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Move the rental back in time: it started three days ago and was due
+	// back 26 hours ago.
+	err := parkingLotServer.ParkingLotService.DB.Model(&rental).Updates(map[string]interface{}{
+		"started_at":     time.Now().Add(-72 * time.Hour),
+		"planned_end_at": time.Now().Add(-26 * time.Hour),
+		"planned_amount": 80,
+	}).Error
+	assert.NoError(t, err)
+
+	response := send(router, "PUT", "/cars/RES-300/returns", `{"odometer": 300}`)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Late Return - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Two planned days at 40, then two late days at 1.5 times the rate.
+	var returned struct {
+		Rental model.Rental `json:"rental"`
+	}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &returned))
+	assert.Equal(t, 200.0, returned.Rental.Amount)
+	assert.Equal(t, 120.0, returned.Rental.Adjustment)
+}
@@ -15,13 +15,13 @@ func TestReturnCar(t *testing.T) {
 
 	router := setupRouter()
 
-	// Create a kilometers payload for returning the car.
-	kilometersPayload := map[string]float64{
-		"kilometers": 250.7,
+	// Create an odometer payload for returning the car.
+	odometerPayload := map[string]float64{
+		"odometer": 750.7,
 	}
 
 	// Convert the payload to JSON.
-	payloadBytes, err := json.Marshal(kilometersPayload)
+	payloadBytes, err := json.Marshal(odometerPayload)
 	assert.NoError(t, err)
 
 	// Create a PUT request to return the car with registration "Reg1".
@@ -53,13 +53,13 @@ func TestReturnCar2(t *testing.T) {
 
 	router := setupRouter()
 
-	// Create a kilometers payload for returning the car.
-	kilometersPayload := map[string]float64{
-		"kilometers": -500,
+	// Create an odometer payload for returning the car.
+	odometerPayload := map[string]float64{
+		"odometer": -500,
 	}
 
 	// Convert the payload to JSON.
-	payloadBytes, err := json.Marshal(kilometersPayload)
+	payloadBytes, err := json.Marshal(odometerPayload)
 	assert.NoError(t, err)
 
 	// Create a PUT request to return the car with registration "Reg1".
@@ -70,7 +70,7 @@ func TestReturnCar2(t *testing.T) {
 	response := httptest.NewRecorder()
 
 	fmt.Printf("\n")
-	fmt.Printf("Test Return Car 2 - With negative kilometers\n")
+	fmt.Printf("Test Return Car 2 - With negative odometer\n")
 
 	// Serve the HTTP request.
 	router.ServeHTTP(response, request)
@@ -83,13 +83,13 @@ func TestReturnCar3(t *testing.T) {
 
 	router := setupRouter()
 
-	// Create a kilometers payload for returning the car.
-	kilometersPayload := map[string]float64{
-		"kilometers": 250.7,
+	// Create an odometer payload for returning the car.
+	odometerPayload := map[string]float64{
+		"odometer": 750.7,
 	}
 
 	// Convert the payload to JSON.
-	payloadBytes, err := json.Marshal(kilometersPayload)
+	payloadBytes, err := json.Marshal(odometerPayload)
 	assert.NoError(t, err)
 
 	// Create a PUT request to return a non-existing car with registration "RegXXX".
@@ -108,3 +108,33 @@ func TestReturnCar3(t *testing.T) {
 	fmt.Printf("Test Return Car 3 - HTTP Status Code: %d (Must be 404)\n", response.Code)
 	assert.Equal(t, http.StatusNotFound, response.Code)
 }
+
+func TestReturnCar4(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create an odometer payload lower than the mileage at checkout (1000).
+	odometerPayload := map[string]float64{
+		"odometer": 900,
+	}
+
+	// Convert the payload to JSON.
+	payloadBytes, err := json.Marshal(odometerPayload)
+	assert.NoError(t, err)
+
+	// Create a PUT request to return the rented car with registration "Reg3".
+	request, err := http.NewRequest("PUT", "/cars/Reg3/returns", bytes.NewBuffer(payloadBytes))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Return Car 4 - With odometer lower than at checkout\n")
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("Test Return Car 4 - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
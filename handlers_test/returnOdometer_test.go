@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOdometerHistory(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the odometer readings of the car "Reg1".
+	request, err := http.NewRequest("GET", "/cars/Reg1/odometer", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Odometer History - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a slice of readings.
+	var readings []model.OdometerReading
+	err = json.Unmarshal(response.Body.Bytes(), &readings)
+	assert.NoError(t, err)
+
+	// The car was rented at 500 and returned at 750.7.
+	fmt.Printf("Test Odometer History - Number of readings: %d (Must be 2)\n", len(readings))
+	assert.Len(t, readings, 2)
+	assert.Equal(t, model.OdometerCheckout, readings[0].Source)
+	assert.Equal(t, 750.7, readings[1].Reading)
+}
+
+func TestPendingReviews(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a GET request for the rentals flagged for review.
+	request, err := http.NewRequest("GET", "/rentals/reviews", nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Pending Reviews - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	// Convert the response body into a slice of rentals.
+	var rentals []model.Rental
+	err = json.Unmarshal(response.Body.Bytes(), &rentals)
+	assert.NoError(t, err)
+
+	// 250.7 km driven within seconds is implausible and must be flagged.
+	fmt.Printf("Test Pending Reviews - Number of rentals: %d (Must be 1)\n", len(rentals))
+	assert.Len(t, rentals, 1)
+	assert.Equal(t, "Reg1", rentals[0].Car.Registration)
+}
@@ -0,0 +1,87 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// searchCars serves a car search and decodes its matches.
+func searchCars(t *testing.T, query string) (int, []service.CarMatch) {
+	router := setupRouter()
+
+	request, err := http.NewRequest("GET", "/cars/search?"+query, nil)
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	var matches []service.CarMatch
+	if response.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &matches))
+	}
+	return response.Code, matches
+}
+
+func TestSearchCarsByRegistration(t *testing.T) {
+
+	// Spaces, dashes and case are ignored in registrations.
+	code, matches := searchCars(t, "q=r-E+g+4")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Search Cars By Registration - HTTP Status Code: %d (Must be 200)\n", code)
+	assert.Equal(t, http.StatusOK, code)
+
+	if assert.NotEmpty(t, matches) {
+		fmt.Printf("Test Search Cars By Registration - First Match: %s (Must be Reg4)\n", matches[0].Car.Registration)
+		assert.Equal(t, "Reg4", matches[0].Car.Registration)
+		assert.EqualValues(t, "registration", matches[0].Matched)
+		assert.Equal(t, 1.0, matches[0].Score)
+	}
+}
+
+func TestSearchCarsWithWildcard(t *testing.T) {
+
+	// A forgotten character is written as ?.
+	code, matches := searchCars(t, "q=REG%3F")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Search Cars With Wildcard - HTTP Status Code: %d (Must be 200)\n", code)
+	assert.Equal(t, http.StatusOK, code)
+
+	fmt.Printf("Test Search Cars With Wildcard - Number of Matches: %d (Must be >= 5)\n", len(matches))
+	assert.True(t, len(matches) >= 5)
+}
+
+func TestSearchCarsByModel(t *testing.T) {
+
+	// A misspelled model still finds the car, ahead of the other models.
+	code, matches := searchCars(t, "q=modle5")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Search Cars By Model - HTTP Status Code: %d (Must be 200)\n", code)
+	assert.Equal(t, http.StatusOK, code)
+
+	if assert.NotEmpty(t, matches) {
+		fmt.Printf("Test Search Cars By Model - First Match: %s (Must be Model5)\n", matches[0].Car.CarModel)
+		assert.Equal(t, "Model5", matches[0].Car.CarModel)
+		assert.EqualValues(t, "model", matches[0].Matched)
+	}
+}
+
+func TestSearchCarsWithoutQuery(t *testing.T) {
+
+	code, _ := searchCars(t, "limit=5")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Search Cars Without Query - HTTP Status Code: %d (Must be 400)\n", code)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,246 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abdeel07/backend-go-cars/fleetpb"
+	"github.com/abdeel07/backend-go-cars/idempotency"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// registrations returns the registrations of a list of cars.
+func registrations(t *testing.T, response *httptest.ResponseRecorder) []string {
+	var cars []model.Car
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &cars))
+
+	var list []string
+	for _, car := range cars {
+		list = append(list, car.Registration)
+	}
+	return list
+}
+
+func TestTenantIsolation(t *testing.T) {
+
+	router := setupRouter()
+
+	// Franchise A adds a car.
+	response := send(router, "POST", "/cars", `{"model": "TenantA", "registration": "RegTenant", "category": "JDAR"}`, tenant.Header, "franchise-a")
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Tenant Isolation - Add HTTP Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var car model.Car
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, "franchise-a", car.TenantID)
+
+	// Franchise B and the default tenant can neither read nor rent it.
+	for _, id := range []string{"franchise-b", ""} {
+		response = send(router, "GET", "/cars/RegTenant", "", tenant.Header, id)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+
+		response = send(router, "PUT", "/cars/RegTenant/rentals", "", tenant.Header, id)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+
+		response = send(router, "GET", "/cars", "", tenant.Header, id)
+		assert.NotContains(t, registrations(t, response), "RegTenant")
+
+		response = send(router, "POST", "/rentals", `{"category": "JDAR"}`, tenant.Header, id)
+		assert.Equal(t, http.StatusConflict, response.Code)
+	}
+
+	// Franchise A only sees its own car.
+	response = send(router, "GET", "/cars", "", tenant.Header, "franchise-a")
+	assert.Equal(t, []string{"RegTenant"}, registrations(t, response))
+
+	response = send(router, "PUT", "/cars/RegTenant/rentals", "", tenant.Header, "franchise-a")
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response = send(router, "PUT", "/cars/RegTenant/returns", `{"odometer": 10}`, tenant.Header, "franchise-b")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = send(router, "PUT", "/cars/RegTenant/returns", `{"odometer": 10}`, tenant.Header, "franchise-a")
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestTenantRegistrationUniqueness(t *testing.T) {
+
+	router := setupRouter()
+
+	// Registrations are unique within a tenant only.
+	response := send(router, "POST", "/cars", `{"model": "TenantB", "registration": "RegTenant"}`, tenant.Header, "franchise-b")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Tenant Registration Uniqueness - HTTP Status Code: %d (Must be 201)\n", response.Code)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	response = send(router, "POST", "/cars", `{"model": "TenantA", "registration": "RegTenant"}`, tenant.Header, "franchise-a")
+
+	fmt.Printf("Test Tenant Registration Uniqueness - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
+	// Each tenant gets its own car under the same registration.
+	var car model.Car
+	response = send(router, "GET", "/cars/RegTenant", "", tenant.Header, "franchise-b")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, "TenantB", car.CarModel)
+
+	response = send(router, "GET", "/cars/RegTenant", "", tenant.Header, "franchise-a")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &car))
+	assert.Equal(t, "TenantA", car.CarModel)
+
+	// Deleting one leaves the other.
+	response = send(router, "DELETE", "/cars/RegTenant", "", tenant.Header, "franchise-b")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = send(router, "GET", "/cars/RegTenant", "", tenant.Header, "franchise-a")
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestTenantReports(t *testing.T) {
+
+	router := setupRouter()
+
+	// Raw SQL reports are restricted to the tenant too.
+	var rows []map[string]interface{}
+	response := send(router, "GET", "/reports/revenue", "", tenant.Header, "franchise-a")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Tenant Reports - Rows: %d (Must be 1)\n", len(rows))
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "RegTenant", rows[0]["registration"])
+	}
+
+	response = send(router, "GET", "/reports/utilization", "")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &rows))
+	for _, row := range rows {
+		assert.NotEqual(t, "RegTenant", row["registration"])
+	}
+}
+
+func TestTenantIdempotencyKeys(t *testing.T) {
+
+	router := setupRouter()
+
+	fmt.Printf("\n")
+
+	// The same key sent by two tenants names two different requests.
+	for _, id := range []string{"franchise-a", "franchise-b"} {
+		response := send(router, "PUT", "/cars/RegTenant/status", `{"status": "maintenance"}`, tenant.Header, id, idempotency.KeyHeader, "tenant-status")
+
+		fmt.Printf("Test Tenant Idempotency Keys - Tenant %s - Replayed: %q (Must be \"\")\n", id, response.Header().Get(idempotency.ReplayedHeader))
+		assert.Empty(t, response.Header().Get(idempotency.ReplayedHeader))
+	}
+}
+
+func TestTenantResolution(t *testing.T) {
+
+	router, s := setupServer()
+	s.Tenants.Tokens = map[string]string{"token-a": "franchise-a"}
+
+	// A bearer token resolves to its tenant.
+	response := send(router, "GET", "/cars/RegTenant", "", "Authorization", "Bearer token-a")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Tenant Resolution - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.Equal(t, http.StatusOK, send(router, "GET", "/cars/RegTenant", "", "Authorization", "Bearer token-a", tenant.Header, "franchise-a").Code)
+	assert.Equal(t, http.StatusUnauthorized, send(router, "GET", "/cars/RegTenant", "", "Authorization", "Bearer token-x").Code)
+	assert.Equal(t, http.StatusForbidden, send(router, "GET", "/cars/RegTenant", "", "Authorization", "Bearer token-a", tenant.Header, "franchise-b").Code)
+
+	// With tokens, anonymous requests are refused even though there is a
+	// default tenant, but the probes still answer.
+	response = send(router, "GET", "/cars", "")
+
+	fmt.Printf("Test Tenant Resolution - Anonymous Status Code: %d (Must be 401)\n", response.Code)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, http.StatusOK, send(router, "GET", "/healthz", "").Code)
+
+	// Without tokens, the header names the tenant unless it is turned off.
+	s.Tenants.Tokens = nil
+	assert.Equal(t, http.StatusOK, send(router, "GET", "/cars/RegTenant", "", tenant.Header, "franchise-a").Code)
+	assert.Equal(t, http.StatusBadRequest, send(router, "GET", "/cars/RegTenant", "", tenant.Header, "Franchise A").Code)
+
+	// Anonymous requests get the default tenant, if there is one.
+	assert.Equal(t, http.StatusOK, send(router, "GET", "/cars", "").Code)
+	s.Tenants.Default = ""
+	assert.Equal(t, http.StatusUnauthorized, send(router, "GET", "/cars", "").Code)
+
+	s.Tenants.AllowHeader = false
+	assert.Equal(t, http.StatusForbidden, send(router, "GET", "/cars/RegTenant", "", tenant.Header, "franchise-a").Code)
+}
+
+func TestTenantHeaderWithTokens(t *testing.T) {
+
+	router, s := setupServer()
+	s.Tenants.Tokens = map[string]string{"token-a": "franchise-a"}
+
+	// Once tokens are configured, the header alone does not reach the cars
+	// of franchise A, even where a gateway was trusted before.
+	fmt.Printf("\n")
+	for _, path := range []string{"/cars/RegTenant", "/cars"} {
+		response := send(router, "GET", path, "", tenant.Header, "franchise-a")
+
+		fmt.Printf("Test Tenant Header With Tokens - %s - HTTP Status Code: %d (Must be 403)\n", path, response.Code)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		assert.NotContains(t, response.Body.String(), "RegTenant")
+	}
+
+	response := send(router, "PUT", "/cars/RegTenant/status", `{"status": "maintenance"}`, tenant.Header, "franchise-a")
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestTenantGraphQLAndGRPC(t *testing.T) {
+
+	router := setupRouter()
+
+	// GraphQL queries run on behalf of the tenant of the request.
+	body := `{"query": "{ car(registration: \"RegTenant\") { model } }"}`
+	var result struct {
+		Data struct {
+			Car *struct {
+				Model string `json:"model"`
+			} `json:"car"`
+		} `json:"data"`
+	}
+	response := send(router, "POST", "/graphql", body, tenant.Header, "franchise-b")
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &result))
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Tenant GraphQL - Car of franchise A found by franchise B: %v (Must be false)\n", result.Data.Car != nil)
+	assert.Nil(t, result.Data.Car)
+
+	// gRPC calls carry the tenant in their metadata.
+	client := setupGRPCClient(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "franchise-b")
+	_, err := client.GetCar(ctx, &fleetpb.GetCarRequest{Registration: "RegTenant"})
+
+	fmt.Printf("Test Tenant gRPC - Code: %s (Must be NotFound)\n", status.Code(err))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "franchise-a")
+	car, err := client.GetCar(ctx, &fleetpb.GetCarRequest{Registration: "RegTenant"})
+	assert.NoError(t, err)
+	assert.Equal(t, "TenantA", car.GetModel())
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer unknown")
+	_, err = client.GetCar(ctx, &fleetpb.GetCarRequest{Registration: "RegTenant"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Clean up the car of franchise A.
+	response = send(router, "DELETE", "/cars/RegTenant", "", tenant.Header, "franchise-a")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
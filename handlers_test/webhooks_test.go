@@ -0,0 +1,286 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/server"
+	"github.com/abdeel07/backend-go-cars/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookSecret signs the deliveries received by the test receivers.
+const webhookSecret = "test-webhook-secret"
+
+// newDispatcher returns a dispatcher of the server's outbox which may reach
+// the test receivers, on the loopback address.
+func newDispatcher(s *server.Server) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(s.ParkingLotService.DB, slog.Default())
+	dispatcher.Client = webhook.NewClient(true)
+	return dispatcher
+}
+
+// subscribe registers url for the given events and returns the subscription.
+func subscribe(t *testing.T, router http.Handler, url string, events ...string) model.WebhookSubscription {
+	payload, _ := json.Marshal(map[string]interface{}{"url": url, "events": events, "secret": webhookSecret})
+	request, err := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var subscription model.WebhookSubscription
+	json.Unmarshal(response.Body.Bytes(), &subscription)
+	return subscription
+}
+
+// unsubscribe removes the subscription so later tests do not receive events.
+func unsubscribe(t *testing.T, router http.Handler, subscription model.WebhookSubscription) {
+	request, err := http.NewRequest("DELETE", fmt.Sprintf("/webhooks/%d", subscription.ID), nil)
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
+
+func TestCreateWebhook(t *testing.T) {
+
+	router := setupRouter()
+
+	// Create a POST request with an unknown event type.
+	payload := []byte(`{"url": "http://example.com/hook", "events": ["car.painted"], "secret": "` + webhookSecret + `"}`)
+	request, err := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(payload))
+	assert.NoError(t, err)
+
+	// Record the response.
+	response := httptest.NewRecorder()
+
+	// Serve the HTTP request.
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n------\n")
+	fmt.Printf("Test Create Webhook - HTTP Status Code: %d (Must be 400)\n", response.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// Start a receiver which records the deliveries.
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	subscription := subscribe(t, router, receiver.URL, model.EventCarAdded)
+	defer unsubscribe(t, router, subscription)
+
+	// The secret must not be returned.
+	assert.Empty(t, subscription.Secret)
+
+	// Add a car, which writes a car.added event to the outbox.
+	request, err := http.NewRequest("POST", "/cars", bytes.NewBuffer([]byte(`{"model": "Hooked", "registration": "RegHook"}`)))
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	// Dispatch the outbox.
+	dispatcher := newDispatcher(parkingLotServer)
+	dispatcher.RunOnce(context.Background())
+
+	r := <-received
+	body := <-bodies
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Webhook Delivery - Event: %s (Must be car.added)\n", r.Header.Get(webhook.EventHeader))
+	assert.Equal(t, model.EventCarAdded, r.Header.Get(webhook.EventHeader))
+
+	// The signature must match the body and timestamp.
+	signature := webhook.Sign(webhookSecret, r.Header.Get(webhook.TimestampHeader), body)
+	assert.Equal(t, signature, r.Header.Get(webhook.SignatureHeader))
+
+	var envelope webhook.Envelope
+	err = json.Unmarshal(body, &envelope)
+	assert.NoError(t, err)
+	assert.Contains(t, string(envelope.Data), "RegHook")
+
+	// A delivered event is not sent again.
+	var delivery model.WebhookDelivery
+	err = parkingLotServer.ParkingLotService.DB.Where("subscription_id = ?", subscription.ID).First(&delivery).Error
+	assert.NoError(t, err)
+	response = send(router, "POST", fmt.Sprintf("/webhooks/deliveries/%d/retry", delivery.ID), "")
+
+	fmt.Printf("Test Webhook Delivery - Retry Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// Start a receiver which always fails.
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	subscription := subscribe(t, router, receiver.URL, "*")
+	defer unsubscribe(t, router, subscription)
+
+	// Delete a car, which writes a car.deleted event to the outbox.
+	request, err := http.NewRequest("DELETE", "/cars/RegHook", nil)
+	assert.NoError(t, err)
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	// A single attempt is allowed, so the failure is final.
+	dispatcher := newDispatcher(parkingLotServer)
+	dispatcher.MaxAttempts = 1
+	dispatcher.RunOnce(context.Background())
+
+	// Create a GET request for the dead-letter view.
+	request, err = http.NewRequest("GET", "/webhooks/deliveries?status=dead", nil)
+	assert.NoError(t, err)
+	response = httptest.NewRecorder()
+	router.ServeHTTP(response, request)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Webhook Dead Letter - HTTP Status Code: %d (Must be 200)\n", response.Code)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	var deliveries []model.WebhookDelivery
+	err = json.Unmarshal(response.Body.Bytes(), &deliveries)
+	assert.NoError(t, err)
+
+	fmt.Printf("Test Webhook Dead Letter - Number of deliveries: %d (Must be 1)\n", len(deliveries))
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusInternalServerError, deliveries[0].LastStatusCode)
+		assert.Equal(t, model.EventCarDeleted, deliveries[0].Event.Type)
+	}
+}
+
+func TestWebhookRetryDeletedSubscription(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	// The id and creation date sent by the client are ignored.
+	response := send(router, "POST", "/webhooks", `{"id": 9999, "created_at": "2000-01-01T00:00:00Z", "url": "`+receiver.URL+`", "events": ["car.added"], "secret": "`+webhookSecret+`"}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	var subscription model.WebhookSubscription
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &subscription))
+	assert.NotEqual(t, uint(9999), subscription.ID)
+	assert.True(t, subscription.CreatedAt.After(time.Now().Add(-time.Hour)))
+
+	response = send(router, "POST", "/cars", `{"model": "Hook", "registration": "RegHookRetry"}`)
+	assert.Equal(t, http.StatusCreated, response.Code)
+
+	dispatcher := newDispatcher(parkingLotServer)
+	dispatcher.MaxAttempts = 1
+	dispatcher.RunOnce(context.Background())
+
+	var delivery model.WebhookDelivery
+	err := parkingLotServer.ParkingLotService.DB.Where("subscription_id = ?", subscription.ID).First(&delivery).Error
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeliveryDead, delivery.Status)
+
+	// Once the webhook is deleted, its dead deliveries cannot be retried.
+	unsubscribe(t, router, subscription)
+	response = send(router, "POST", fmt.Sprintf("/webhooks/deliveries/%d/retry", delivery.ID), "")
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Webhook Retry Deleted Subscription - HTTP Status Code: %d (Must be 409)\n", response.Code)
+	assert.Equal(t, http.StatusConflict, response.Code)
+}
+
+func TestWebhookPrivateTarget(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+	parkingLotServer.AllowPrivateWebhooks = false
+
+	// URLs naming the network of the deployment are refused.
+	for _, target := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest", "http://[::1]/hook", "http://10.0.0.5/hook"} {
+		response := send(router, "POST", "/webhooks", `{"url": "`+target+`", "events": ["*"], "secret": "`+webhookSecret+`"}`)
+
+		fmt.Printf("Test Webhook Private Target - %s: %d (Must be 400)\n", target, response.Code)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	}
+
+	// A host name resolving to one is refused when the delivery is sent.
+	var received atomic.Bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	client := webhook.NewClient(false)
+	request, err := http.NewRequest("POST", strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1), nil)
+	assert.NoError(t, err)
+	_, err = client.Do(request)
+
+	fmt.Printf("Test Webhook Private Target - Dial Error: %v (Must be a private address)\n", err)
+	assert.ErrorIs(t, err, webhook.ErrPrivateAddress)
+	assert.False(t, received.Load())
+}
+
+func TestWebhookRedirect(t *testing.T) {
+
+	router, parkingLotServer := setupServer()
+
+	// A receiver redirecting deliveries elsewhere.
+	var redirected atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	subscription := subscribe(t, router, receiver.URL, model.EventCarAdded)
+	defer unsubscribe(t, router, subscription)
+
+	assert.Equal(t, http.StatusCreated, send(router, "POST", "/cars", `{"model": "Hook", "registration": "RegHookRedirect"}`).Code)
+
+	dispatcher := newDispatcher(parkingLotServer)
+	dispatcher.MaxAttempts = 1
+	dispatcher.RunOnce(context.Background())
+
+	var delivery model.WebhookDelivery
+	err := parkingLotServer.ParkingLotService.DB.Where("subscription_id = ?", subscription.ID).First(&delivery).Error
+	assert.NoError(t, err)
+
+	fmt.Printf("\n")
+	fmt.Printf("Test Webhook Redirect - Delivery Status: %s (Must be dead)\n", delivery.Status)
+	assert.Equal(t, model.DeliveryDead, delivery.Status)
+	assert.Equal(t, http.StatusTemporaryRedirect, delivery.LastStatusCode)
+	assert.False(t, redirected.Load())
+}
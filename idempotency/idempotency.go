@@ -0,0 +1,226 @@
+// Package idempotency replays the response of mutating requests retried with
+// the same Idempotency-Key header.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/logging"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"gorm.io/gorm"
+)
+
+// Headers of the protocol.
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength is the longest key accepted, the size of the key column.
+const maxKeyLength = 255
+
+// Store keeps the responses of idempotent requests in the database, so that
+// retries reaching another instance of the API are replayed too.
+type Store struct {
+	DB     *gorm.DB
+	Logger *slog.Logger
+	// TTL is how long a completed response is replayed.
+	TTL time.Duration
+	// LockTimeout is how long a request in progress holds its key, after
+	// which the key is considered abandoned by a crashed instance.
+	LockTimeout time.Duration
+}
+
+// NewStore creates a store keeping responses for 24 hours.
+func NewStore(db *gorm.DB, logger *slog.Logger) *Store {
+	return &Store{DB: db, Logger: logger, TTL: 24 * time.Hour, LockTimeout: time.Minute}
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeError writes an error in the JSON format of the handlers.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Middleware applies the Idempotency-Key header of POST, PUT, PATCH and
+// DELETE requests. The first request with a key is served and its response
+// stored; a retry with the same key and body gets the stored response, while
+// a key reused for a different request, or still in progress, is refused.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			writeError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		// Keys are chosen by the clients, so two tenants may pick the same.
+		if id, ok := tenant.FromContext(r.Context()); ok {
+			key = id + "/" + key
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = io.ReadAll(r.Body); err != nil {
+				writeError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		ctx := r.Context()
+		digest := fingerprint(r, body)
+		entry, acquired, err := s.acquire(ctx, key, digest)
+		if err != nil {
+			s.Logger.ErrorContext(ctx, "Failed to check idempotency key", "error", err)
+			writeError(w, http.StatusInternalServerError, "Failed to check idempotency key")
+			return
+		}
+		if !acquired {
+			s.replay(w, entry, digest)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not stored so that the client can retry them.
+		if recorder.status >= http.StatusInternalServerError {
+			if err := s.DB.WithContext(context.WithoutCancel(ctx)).Delete(&model.IdempotencyKey{}, "idempotency_key = ?", key).Error; err != nil {
+				s.Logger.ErrorContext(ctx, "Failed to release idempotency key", "error", err)
+			}
+			return
+		}
+
+		header := w.Header().Clone()
+		header.Del(logging.RequestIDHeader)
+		entry.Completed = true
+		entry.StatusCode = recorder.status
+		entry.Header = header
+		entry.Body = recorder.body.Bytes()
+		entry.ExpiresAt = time.Now().Add(s.TTL)
+		err = s.DB.WithContext(context.WithoutCancel(ctx)).
+			Select("completed", "status_code", "header", "body", "expires_at").
+			Updates(&entry).Error
+		if err != nil {
+			s.Logger.ErrorContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+	})
+}
+
+// acquire claims the key for a new request. When the key is already taken by
+// a live entry, that entry is returned instead.
+func (s *Store) acquire(ctx context.Context, key, fingerprint string) (model.IdempotencyKey, bool, error) {
+	db := s.DB.WithContext(ctx)
+
+	// Expired entries, including requests abandoned in progress, free their key.
+	if err := db.Where("idempotency_key = ? AND expires_at < ?", key, time.Now()).Delete(&model.IdempotencyKey{}).Error; err != nil {
+		return model.IdempotencyKey{}, false, err
+	}
+
+	entry := model.IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(s.LockTimeout),
+	}
+	createErr := db.Create(&entry).Error
+	if createErr == nil {
+		return entry, true, nil
+	}
+
+	// The insert failed, most likely because another request holds the key.
+	var existing model.IdempotencyKey
+	err := db.Where("idempotency_key = ?", key).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return existing, false, createErr
+	}
+	return existing, false, err
+}
+
+// replay answers a request whose key is already taken.
+func (s *Store) replay(w http.ResponseWriter, entry model.IdempotencyKey, fingerprint string) {
+	if entry.Fingerprint != fingerprint {
+		writeError(w, http.StatusConflict, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if !entry.Completed {
+		writeError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(entry.StatusCode)
+	w.Write(entry.Body)
+}
+
+// Purge deletes the expired keys.
+func (s *Store) Purge(ctx context.Context) (int64, error) {
+	result := s.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// Run purges the expired keys every interval until ctx is cancelled.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil && ctx.Err() == nil {
+				s.Logger.Error("Failed to purge idempotency keys", "error", err)
+			}
+		}
+	}
+}
+
+// responseRecorder copies the response written by the handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
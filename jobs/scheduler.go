@@ -0,0 +1,281 @@
+// Package jobs runs background jobs at fixed intervals. Every replica of the
+// API runs a scheduler; a lock row in the database makes sure only one of
+// them runs a job at a time, and the recorded runs tell the others when it
+// is next due.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrLocked     = errors.New("job is running on another replica")
+)
+
+// Func runs a job once and summarizes what it did.
+type Func func(ctx context.Context) (string, error)
+
+// Job is a function run every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      Func
+}
+
+// Scheduler runs the registered jobs when they are due.
+type Scheduler struct {
+	DB     *gorm.DB
+	Logger *slog.Logger
+	// Owner identifies the replica in locks and runs.
+	Owner string
+	// LockTTL is how long a job stays locked by a replica that stopped
+	// without releasing it; runs must finish within it.
+	LockTTL time.Duration
+
+	mu   sync.Mutex
+	jobs []Job
+}
+
+// NewScheduler returns a scheduler without jobs, owned by this process.
+func NewScheduler(db *gorm.DB, logger *slog.Logger) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		DB:      db,
+		Logger:  logger,
+		Owner:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		LockTTL: 10 * time.Minute,
+	}
+}
+
+// Register adds a job, replacing any job of the same name.
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.jobs {
+		if s.jobs[i].Name == job.Name {
+			s.jobs[i] = job
+			return
+		}
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// SetInterval changes the interval of a registered job.
+func (s *Scheduler) SetInterval(name string, interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.jobs {
+		if s.jobs[i].Name == name {
+			s.jobs[i].Interval = interval
+			return nil
+		}
+	}
+	return ErrUnknownJob
+}
+
+// Jobs returns the registered jobs, in registration order.
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Job(nil), s.jobs...)
+}
+
+// job returns the registered job with the given name.
+func (s *Scheduler) job(name string) (Job, error) {
+	for _, job := range s.Jobs() {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return Job{}, ErrUnknownJob
+}
+
+// Run starts the due jobs every interval until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDue(ctx); err != nil && ctx.Err() == nil {
+			s.Logger.ErrorContext(ctx, "Failed to schedule jobs", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs, one after the other, the jobs whose last run started at least
+// their interval ago and that no other replica is running.
+func (s *Scheduler) RunDue(ctx context.Context) error {
+	for _, job := range s.Jobs() {
+		if job.Interval <= 0 {
+			continue
+		}
+		_, err := s.run(ctx, job, true)
+		if err != nil && !errors.Is(err, ErrLocked) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunNow runs a job immediately, whether it is due or not, and returns its
+// run. The job failing is recorded in the run, not returned.
+func (s *Scheduler) RunNow(ctx context.Context, name string) (model.JobRun, error) {
+	job, err := s.job(name)
+	if err != nil {
+		return model.JobRun{}, err
+	}
+	run, err := s.run(ctx, job, false)
+	if err != nil {
+		return model.JobRun{}, err
+	}
+	return *run, nil
+}
+
+// run runs a job under its lock. When onlyDue is true, it does nothing and
+// returns a nil run unless the job is due. Jobs work on every tenant, even
+// when run on behalf of a request.
+func (s *Scheduler) run(ctx context.Context, job Job, onlyDue bool) (*model.JobRun, error) {
+	ctx = tenant.Without(ctx)
+	locked, err := s.lock(ctx, job.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrLocked
+	}
+	defer s.unlock(job.Name)
+
+	// Another replica may have run the job between the check of the
+	// caller and the lock: the last run is read under the lock.
+	if onlyDue {
+		last, err := s.LastRun(ctx, job.Name)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && time.Since(last.StartedAt) < job.Interval {
+			return nil, nil
+		}
+	}
+
+	run := model.JobRun{Job: job.Name, Owner: s.Owner, Status: model.JobRunning, StartedAt: time.Now()}
+	if err := s.DB.WithContext(ctx).Create(&run).Error; err != nil {
+		return nil, err
+	}
+
+	summary, jobErr := s.call(ctx, job)
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Summary = summary
+	run.Status = model.JobSucceeded
+	if jobErr != nil {
+		run.Status = model.JobFailed
+		run.Error = jobErr.Error()
+		s.Logger.ErrorContext(ctx, "Job failed", "job", job.Name, "error", jobErr)
+	} else {
+		s.Logger.InfoContext(ctx, "Job finished", "job", job.Name, "summary", summary,
+			"duration", now.Sub(run.StartedAt))
+	}
+
+	// The run is recorded even if ctx was cancelled while the job ran.
+	return &run, s.DB.WithContext(context.WithoutCancel(ctx)).Save(&run).Error
+}
+
+// call runs a job, turning a panic into an error so that one failing job
+// does not stop the scheduler.
+func (s *Scheduler) call(ctx context.Context, job Job) (summary string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// lock claims the lock of a job, reporting false if another replica holds it.
+func (s *Scheduler) lock(ctx context.Context, name string) (bool, error) {
+	db := s.DB.WithContext(ctx)
+
+	// Expired locks, left by replicas that stopped during a run, are freed.
+	if err := db.Where("job = ? AND expires_at < ?", name, time.Now()).Delete(&model.JobLock{}).Error; err != nil {
+		return false, err
+	}
+
+	createErr := db.Create(&model.JobLock{Job: name, Owner: s.Owner, ExpiresAt: time.Now().Add(s.LockTTL)}).Error
+	if createErr == nil {
+		return true, nil
+	}
+
+	// The insert failed, most likely because another replica holds the lock.
+	var existing model.JobLock
+	err := db.Where("job = ?", name).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, createErr
+	}
+	return false, err
+}
+
+// unlock releases the lock of a job held by this replica.
+func (s *Scheduler) unlock(name string) {
+	err := s.DB.Where("job = ? AND owner = ?", name, s.Owner).Delete(&model.JobLock{}).Error
+	if err != nil {
+		s.Logger.Error("Failed to release job lock", "job", name, "error", err)
+	}
+}
+
+// LastRun returns the most recent run of a job, or nil if it never ran.
+func (s *Scheduler) LastRun(ctx context.Context, name string) (*model.JobRun, error) {
+	var run model.JobRun
+	err := s.DB.WithContext(ctx).Where("job = ?", name).Order("started_at DESC, id DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RunFilter selects job runs.
+type RunFilter struct {
+	Job    string
+	Status model.JobStatus
+	Limit  int
+}
+
+// History returns the runs matching the filter, most recent first.
+func (s *Scheduler) History(ctx context.Context, filter RunFilter) ([]model.JobRun, error) {
+	query := s.DB.WithContext(ctx).Order("started_at DESC, id DESC")
+	if filter.Job != "" {
+		query = query.Where("job = ?", filter.Job)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var runs []model.JobRun
+	err := query.Find(&runs).Error
+	return runs, err
+}
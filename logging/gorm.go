@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger sends GORM logs to slog. Queries slower than SlowThreshold are
+// logged as warnings, failed queries as errors and the others at debug level.
+// Missing records are expected by the service layer and are not errors.
+type GormLogger struct {
+	Logger        *slog.Logger
+	SlowThreshold time.Duration
+}
+
+// NewGormLogger creates a GORM logger writing to the given slog logger.
+func NewGormLogger(logger *slog.Logger, slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{Logger: logger, SlowThreshold: slowThreshold}
+}
+
+// LogMode is a no-op: the level is controlled by the slog logger.
+func (l *GormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.Logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.Logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.Logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.Logger.ErrorContext(ctx, "query failed", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("duration", elapsed), slog.String("error", err.Error()))
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		sql, rows := fc()
+		l.Logger.WarnContext(ctx, "slow query", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("duration", elapsed), slog.Duration("threshold", l.SlowThreshold))
+	case l.Logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.Logger.DebugContext(ctx, "query", slog.String("sql", sql), slog.Int64("rows", rows),
+			slog.Duration("duration", elapsed))
+	}
+}
@@ -1,29 +1,212 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/abdeel07/backend-go-cars/config"
+	"github.com/abdeel07/backend-go-cars/grpcapi"
+	"github.com/abdeel07/backend-go-cars/logging"
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/ratelimit"
 	"github.com/abdeel07/backend-go-cars/routes"
 	"github.com/abdeel07/backend-go-cars/server"
 	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/webhook"
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Error loading configuration", "error", err)
+		os.Exit(1)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		slog.Error("Error configuring logging", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
 	router := mux.NewRouter()
 
-	db, err := service.InitializeDB("root:@tcp(127.0.0.1:3306)/parking_lot?charset=utf8&parseTime=True&loc=Local")
+	db, err := service.InitializeDB(cfg.DatabaseDSN, &gorm.Config{
+		Logger: logging.NewGormLogger(logger, cfg.SlowQueryThreshold),
+	})
 	if err != nil {
-		fmt.Println("Error initializing database:", err)
+		logger.Error("Error initializing database", "error", err)
+		os.Exit(1)
 	}
 
-	service.MigrateDB(db)
+	if err := service.MigrateDB(db); err != nil {
+		// Cars whose registrations only differ by case or separators can no
+		// longer be told apart; they need to be corrected by hand.
+		if errors.Is(err, service.ErrRegistrationCollisions) {
+			collisions, _ := service.RegistrationCollisions(context.Background(), db)
+			for _, collision := range collisions {
+				logger.Error("Registration shared by several cars", "tenant", collision.TenantID,
+					"registration", collision.CanonicalRegistration, "cars", collision.Registrations)
+			}
+		}
+		logger.Error("Error migrating database", "error", err)
+		os.Exit(1)
+	}
 
 	parkingLotServer := server.NewServer(db)
+	parkingLotServer.Events.Heartbeat = cfg.EventsHeartbeat
+	parkingLotServer.Events.CommitWindow = cfg.EventsCommitWindow
+	parkingLotServer.Idempotency.TTL = cfg.IdempotencyTTL
+	rateLimitStore := ratelimit.NewMemoryStore()
+	parkingLotServer.RateLimit.Store = rateLimitStore
+	parkingLotServer.RateLimit.Default = cfg.RateLimit
+	parkingLotServer.RateLimit.Routes = cfg.RouteLimits
+	parkingLotServer.RateLimit.TrustProxy = cfg.RateLimitTrustProxy
+	parkingLotServer.RateLimit.APIKeys = cfg.RateLimitAPIKeys
+	parkingLotServer.Tenants.Tokens = cfg.TenantTokens
+	parkingLotServer.Tenants.AllowHeader = cfg.TenantHeader
+	parkingLotServer.Tenants.Default = cfg.DefaultTenant
+	parkingLotServer.ParkingLotService.Eligibility = cfg.Eligibility
+	if cfg.PaymentProvider == "fake" {
+		parkingLotServer.ParkingLotService.Payments = payment.NewFake()
+	}
+	parkingLotServer.ParkingLotService.Deposit = cfg.Deposit
+	parkingLotServer.ParkingLotService.CaptureAttempts = cfg.CaptureAttempts
+	parkingLotServer.ParkingLotService.Returns = service.ReturnPolicy{
+		LateRateMultiplier: cfg.LateReturnMultiplier,
+		Grace:              cfg.LateReturnGrace,
+	}
+	parkingLotServer.ParkingLotService.ReminderLead = cfg.PickupReminderLead
+	if err := setupNotifications(parkingLotServer, cfg); err != nil {
+		logger.Error("Error configuring notifications", "error", err)
+		os.Exit(1)
+	}
+	parkingLotServer.Jobs.LockTTL = cfg.JobLockTTL
+	parkingLotServer.AdminToken = cfg.AdminToken
+	parkingLotServer.AllowPrivateWebhooks = cfg.WebhookAllowPrivate
+	err = errors.Join(
+		parkingLotServer.Jobs.SetInterval(server.OverdueRentalsJob, cfg.OverdueCheckInterval),
+		parkingLotServer.Jobs.SetInterval(server.PickupRemindersJob, cfg.PickupReminderInterval),
+		parkingLotServer.Jobs.SetInterval(server.DepositCapturesJob, cfg.CaptureRetryInterval),
+	)
+	if err != nil {
+		logger.Error("Error scheduling jobs", "error", err)
+		os.Exit(1)
+	}
 
 	routes.SetupRoutes(router, parkingLotServer)
 
-	http.ListenAndServe(":8080", router)
-	fmt.Println("Server started on port 8080")
+	httpServer := &http.Server{Addr: cfg.HTTPAddr, Handler: router}
+	grpcServer := grpcapi.NewGRPCServer(parkingLotServer)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		logger.Info("Server starting", "addr", cfg.HTTPAddr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Server stopped", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	go func() {
+		listener, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			logger.Error("gRPC server stopped", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("gRPC server starting", "addr", cfg.GRPCAddr)
+		if err := grpcServer.Serve(listener); err != nil {
+			logger.Error("gRPC server stopped", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	dispatcher := webhook.NewDispatcher(db, logger)
+	if cfg.WebhookAllowPrivate {
+		dispatcher.Client = webhook.NewClient(true)
+	}
+	go dispatcher.Run(ctx, cfg.WebhookPollInterval)
+	go parkingLotServer.Events.Run(ctx, cfg.EventsPollInterval)
+	go parkingLotServer.Idempotency.Run(ctx, time.Hour)
+	go rateLimitStore.Run(ctx, time.Minute)
+	go parkingLotServer.Jobs.Run(ctx, cfg.JobPollInterval)
+	go parkingLotServer.Notifications.Run(ctx, cfg.NotifyPollInterval)
+
+	<-ctx.Done()
+
+	// Fail readiness first so load balancers drain traffic, then stop
+	// accepting connections and wait for in-flight requests.
+	logger.Info("Shutting down", "delay", cfg.ShutdownDelay)
+	parkingLotServer.BeginShutdown()
+	time.Sleep(cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Error shutting down", "error", err)
+	}
+
+	// Watch streams end when the event broker stops with ctx; force the
+	// remaining calls to stop if they outlive the shutdown timeout.
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+}
+
+// setupNotifications chooses how notifications are sent: by the mail server
+// and the SMS gateway when configured, and by the sink for the other
+// channels. Notifications are only queued on the channels that can be sent.
+func setupNotifications(s *server.Server, cfg config.Config) error {
+	outbox := s.ParkingLotService.Notifications
+	if cfg.NotifyTemplatesDir != "" {
+		templates, err := notify.LoadTemplates(os.DirFS(cfg.NotifyTemplatesDir))
+		if err != nil {
+			return err
+		}
+		outbox.Templates = templates
+	}
+	outbox.Templates.Default = cfg.NotifyDefaultLocale
+
+	if cfg.NotifySink != "" {
+		sinks, err := notify.NewSinks(cfg.NotifySink)
+		if err != nil {
+			return err
+		}
+		for _, sink := range sinks {
+			s.Notifications.Notifiers[sink.Channel()] = sink
+		}
+	}
+	if cfg.SMTPAddr != "" {
+		s.Notifications.Notifiers[model.ChannelEmail] = notify.NewSMTP(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	}
+	if cfg.SMSURL != "" {
+		s.Notifications.Notifiers[model.ChannelSMS] = notify.NewSMS(cfg.SMSURL, cfg.SMSToken, cfg.SMSFrom)
+	}
+
+	for _, channel := range []model.NotificationChannel{model.ChannelEmail, model.ChannelSMS} {
+		if _, ok := s.Notifications.Notifiers[channel]; ok {
+			outbox.Channels = append(outbox.Channels, channel)
+		}
+	}
+	return nil
 }
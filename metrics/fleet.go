@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// fleetCollector reads the fleet state from the database on every scrape, so
+// the values are shared by every replica and survive restarts.
+type fleetCollector struct {
+	db            *gorm.DB
+	cars          *prometheus.Desc
+	rentalsOpened *prometheus.Desc
+	rentalsClosed *prometheus.Desc
+	scrapeErrors  *prometheus.Desc
+}
+
+func newFleetCollector(db *gorm.DB) *fleetCollector {
+	return &fleetCollector{
+		db: db,
+		cars: prometheus.NewDesc("fleet_cars",
+			"Number of cars in the fleet by status.", []string{"status"}, nil),
+		rentalsOpened: prometheus.NewDesc("fleet_rentals_opened_total",
+			"Number of rentals opened.", nil, nil),
+		rentalsClosed: prometheus.NewDesc("fleet_rentals_closed_total",
+			"Number of rentals closed by a return.", nil, nil),
+		scrapeErrors: prometheus.NewDesc("fleet_scrape_error",
+			"1 if the fleet metrics could not be read from the database.", nil, nil),
+	}
+}
+
+func (c *fleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cars
+	ch <- c.rentalsOpened
+	ch <- c.rentalsClosed
+	ch <- c.scrapeErrors
+}
+
+func (c *fleetCollector) Collect(ch chan<- prometheus.Metric) {
+	var statuses []struct {
+		Status string
+		Count  float64
+	}
+	err := c.db.Raw("SELECT status, COUNT(*) AS count FROM cars WHERE deleted_at IS NULL GROUP BY status").
+		Scan(&statuses).Error
+
+	var rentals struct {
+		Opened float64
+		Closed float64
+	}
+	if err == nil {
+		err = c.db.Raw("SELECT COUNT(*) AS opened, COUNT(ended_at) AS closed FROM rentals").Scan(&rentals).Error
+	}
+
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.scrapeErrors, prometheus.GaugeValue, 1)
+		return
+	}
+
+	for _, status := range statuses {
+		ch <- prometheus.MustNewConstMetric(c.cars, prometheus.GaugeValue, status.Count, status.Status)
+	}
+	ch <- prometheus.MustNewConstMetric(c.rentalsOpened, prometheus.CounterValue, rentals.Opened)
+	ch <- prometheus.MustNewConstMetric(c.rentalsClosed, prometheus.CounterValue, rentals.Closed)
+	ch <- prometheus.MustNewConstMetric(c.scrapeErrors, prometheus.GaugeValue, 0)
+}
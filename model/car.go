@@ -1,11 +1,69 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Transmission is the gearbox type of a car.
+type Transmission string
+
+const (
+	Manual    Transmission = "manual"
+	Automatic Transmission = "automatic"
+)
+
+// FuelType is the energy source of a car.
+type FuelType string
+
+const (
+	Petrol       FuelType = "petrol"
+	Diesel       FuelType = "diesel"
+	Electric     FuelType = "electric"
+	Hybrid       FuelType = "hybrid"
+	PluginHybrid FuelType = "plugin_hybrid"
+	LPG          FuelType = "lpg"
+	Hydrogen     FuelType = "hydrogen"
+)
+
+// Valid reports whether the transmission is one of the known values.
+func (t Transmission) Valid() bool {
+	return t == Manual || t == Automatic
+}
+
+// Valid reports whether the fuel type is one of the known values.
+func (f FuelType) Valid() bool {
+	switch f {
+	case Petrol, Diesel, Electric, Hybrid, PluginHybrid, LPG, Hydrogen:
+		return true
+	}
+	return false
+}
 
 type Car struct {
 	gorm.Model
-	CarModel     string  `json:"model"`
-	Registration string  `json:"registration" gorm:"unique;not null"`
-	Mileage      float64 `json:"mileage"`
-	Available    bool    `json:"available"`
+	TenantID              string       `json:"tenant_id" gorm:"size:64;not null;default:default;uniqueIndex:idx_cars_tenant_registration,priority:1;uniqueIndex:idx_cars_tenant_canonical_registration,priority:1"`
+	CarModel              string       `json:"model"`
+	Make                  string       `json:"make" gorm:"size:64;index"`
+	Year                  int          `json:"year"`
+	VIN                   string       `json:"vin" gorm:"column:vin;size:17;index"`
+	Colour                string       `json:"colour" gorm:"size:32"`
+	Seats                 int          `json:"seats"`
+	Transmission          Transmission `json:"transmission" gorm:"size:16"`
+	FuelType              FuelType     `json:"fuel_type" gorm:"size:16"`
+	PurchaseDate          *time.Time   `json:"purchase_date"`
+	PurchasePrice         float64      `json:"purchase_price"`
+	Registration          string       `json:"registration" gorm:"not null;uniqueIndex:idx_cars_tenant_registration,priority:2"`
+	CanonicalRegistration string       `json:"canonical_registration" gorm:"size:32;uniqueIndex:idx_cars_tenant_canonical_registration,priority:2"`
+	Category              string       `json:"category" gorm:"size:4;index"`
+	Branch                string       `json:"branch" gorm:"size:64;index"`
+	Mileage               float64      `json:"mileage"`
+	NextServiceMileage    float64      `json:"next_service_mileage"`
+	DailyRate             float64      `json:"daily_rate"`
+	Status                CarStatus    `json:"status" gorm:"size:16;index"`
+	StatusChangedAt       *time.Time   `json:"status_changed_at"`
+	Available             bool         `json:"available" gorm:"-"`
+	LastRentedAt          *time.Time   `json:"last_rented_at"`
+	Version               uint         `json:"version" gorm:"not null;default:1"`
 }
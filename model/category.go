@@ -0,0 +1,47 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// CategoryWildcard matches any letter at a given position of a category pattern.
+const CategoryWildcard = '*'
+
+// acrissPositions lists the letters allowed at each position of an ACRISS code:
+// vehicle size, doors/body type, transmission/drive and fuel/air conditioning.
+var acrissPositions = [4]string{
+	"MNEHCDIJSRFGPULWOX",
+	"BCDWVLSTFJXPQZEMRHYNGK",
+	"MNCABD",
+	"RNDQHIECLSABMFVZUX",
+}
+
+// ErrInvalidCategory is returned when a category code is not a valid ACRISS code.
+var ErrInvalidCategory = errors.New("category must be a 4-letter ACRISS code")
+
+// NormalizeCategory upper-cases a category code and validates it against the
+// ACRISS letter tables. When allowWildcards is true, CategoryWildcard is
+// accepted at any position so the result can be used as a search pattern.
+func NormalizeCategory(code string, allowWildcards bool) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != len(acrissPositions) {
+		return "", ErrInvalidCategory
+	}
+
+	for i, letter := range code {
+		if allowWildcards && letter == CategoryWildcard {
+			continue
+		}
+		if !strings.ContainsRune(acrissPositions[i], letter) {
+			return "", ErrInvalidCategory
+		}
+	}
+
+	return code, nil
+}
+
+// CategoryLikePattern converts a category pattern into a SQL LIKE expression.
+func CategoryLikePattern(pattern string) string {
+	return strings.ReplaceAll(pattern, string(CategoryWildcard), "_")
+}
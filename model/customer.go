@@ -0,0 +1,44 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Customer is a person renting cars.
+type Customer struct {
+	ID              uint       `json:"id" gorm:"primarykey"`
+	TenantID        string     `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	Name            string     `json:"name" gorm:"size:128;not null"`
+	Email           string     `json:"email" gorm:"size:255;index"`
+	Phone           string     `json:"phone" gorm:"size:32"`
+	Locale          string     `json:"locale" gorm:"size:16"`
+	DateOfBirth     *time.Time `json:"date_of_birth"`
+	LicenceNumber   string     `json:"licence_number" gorm:"size:32;index"`
+	LicenceIssuedAt *time.Time `json:"licence_issued_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// BlockedDriver is an entry of the blocklist: customers with its licence
+// number or email may not rent or drive a car.
+type BlockedDriver struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	TenantID      string    `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	LicenceNumber string    `json:"licence_number" gorm:"size:32;index"`
+	Email         string    `json:"email" gorm:"size:255;index"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NormalizeLicenceNumber upper-cases a driving licence number and drops its
+// spaces and separators.
+func NormalizeLicenceNumber(number string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, number)
+}
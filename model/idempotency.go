@@ -0,0 +1,21 @@
+package model
+
+import (
+	"net/http"
+	"time"
+)
+
+// IdempotencyKey stores the response to a mutating request sent with an
+// Idempotency-Key header, so that a retry of the same request gets the same
+// response instead of being applied twice. Key is prefixed with the tenant
+// of the request.
+type IdempotencyKey struct {
+	Key         string      `gorm:"column:idempotency_key;primarykey;size:320"`
+	Fingerprint string      `gorm:"size:64;not null"`
+	Completed   bool        `gorm:"not null"`
+	StatusCode  int         `gorm:"not null"`
+	Header      http.Header `gorm:"serializer:json;type:text"`
+	Body        []byte      `gorm:"type:mediumblob"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
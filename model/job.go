@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// JobLock is held by the replica running a background job, so that the
+// other replicas skip it. Locks expire, so a replica that dies while running
+// a job does not block it forever.
+type JobLock struct {
+	Job       string    `gorm:"primarykey;size:64"`
+	Owner     string    `gorm:"size:128;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// JobStatus is the outcome of a job run.
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobRun records one run of a background job.
+type JobRun struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	Job        string     `json:"job" gorm:"size:64;index;not null"`
+	Owner      string     `json:"owner" gorm:"size:128"`
+	Status     JobStatus  `json:"status" gorm:"size:16;not null"`
+	Summary    string     `json:"summary,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
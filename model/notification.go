@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// NotificationKind is what a customer is told about.
+type NotificationKind string
+
+const (
+	NotifyBookingConfirmation NotificationKind = "booking_confirmation"
+	NotifyPickupReminder      NotificationKind = "pickup_reminder"
+	NotifyOverdueReturn       NotificationKind = "overdue_return"
+	NotifyInvoice             NotificationKind = "invoice"
+)
+
+// NotificationChannel is how a notification reaches the customer.
+type NotificationChannel string
+
+const (
+	ChannelEmail NotificationChannel = "email"
+	ChannelSMS   NotificationChannel = "sms"
+)
+
+// NotificationStatus is the state of a notification in the outbox.
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationDead    NotificationStatus = "dead"
+)
+
+// Notification is a message to a customer, rendered when queued and kept in
+// the outbox until it is sent. Notifications that exhaust their attempts are
+// dead and kept for inspection.
+type Notification struct {
+	ID            uint                `json:"id" gorm:"primarykey"`
+	TenantID      string              `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	CustomerID    uint                `json:"customer_id" gorm:"index;not null"`
+	RentalID      *uint               `json:"rental_id" gorm:"index"`
+	ReservationID *uint               `json:"reservation_id" gorm:"index"`
+	Kind          NotificationKind    `json:"kind" gorm:"size:32;index"`
+	Channel       NotificationChannel `json:"channel" gorm:"size:8"`
+	Recipient     string              `json:"recipient" gorm:"size:255"`
+	Locale        string              `json:"locale" gorm:"size:16"`
+	Subject       string              `json:"subject,omitempty"`
+	Body          string              `json:"body" gorm:"type:text"`
+	Status        NotificationStatus  `json:"status" gorm:"size:16;index"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt time.Time           `json:"next_attempt_at" gorm:"index"`
+	LastError     string              `json:"last_error,omitempty"`
+	SentAt        *time.Time          `json:"sent_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
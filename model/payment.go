@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// PaymentKind tells which step of the deposit workflow a payment records.
+type PaymentKind string
+
+const (
+	// PaymentAuthorization is the deposit held when the car is rented.
+	PaymentAuthorization PaymentKind = "authorization"
+	// PaymentCapture is the final amount taken from the deposit on return.
+	PaymentCapture PaymentKind = "capture"
+	// PaymentRelease is the part of the deposit given back on return.
+	PaymentRelease PaymentKind = "release"
+	// PaymentRefund is money given back after the rental.
+	PaymentRefund PaymentKind = "refund"
+	// PaymentBalance is the part of the final amount the deposit did not
+	// cover, still owed by the customer.
+	PaymentBalance PaymentKind = "balance"
+)
+
+// PaymentStatus is the outcome of a payment.
+type PaymentStatus string
+
+const (
+	PaymentSucceeded PaymentStatus = "succeeded"
+	PaymentFailed    PaymentStatus = "failed"
+	PaymentDue       PaymentStatus = "due"
+	// PaymentPending is a capture not made yet, or to be retried.
+	PaymentPending PaymentStatus = "pending"
+)
+
+// Payment is a movement of money, or an attempt, for a rental.
+type Payment struct {
+	ID        uint          `json:"id" gorm:"primarykey"`
+	TenantID  string        `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	RentalID  uint          `json:"rental_id" gorm:"index;not null"`
+	Kind      PaymentKind   `json:"kind" gorm:"size:16;not null"`
+	Status    PaymentStatus `json:"status" gorm:"size:16;not null"`
+	Amount    float64       `json:"amount"`
+	Provider  string        `json:"provider" gorm:"size:32"`
+	Reference string        `json:"reference" gorm:"size:128"`
+	Reason    string        `json:"reason,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	// Attempts and NextAttemptAt track the tries of a pending capture.
+	Attempts      int        `json:"attempts,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// MaxRegistrationLength is the longest canonical registration, in letters
+// and digits.
+const MaxRegistrationLength = 32
+
+// confusables maps the Cyrillic and Greek capitals drawn like a Latin letter
+// to that letter, so a plate typed on another keyboard layout is the same
+// plate.
+var confusables = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O',
+	'Р': 'P', 'С': 'C', 'Т': 'T', 'У': 'Y', 'Х': 'X', 'І': 'I', 'Ј': 'J',
+	'Ѕ': 'S', 'Α': 'A', 'Β': 'B', 'Ε': 'E', 'Ζ': 'Z', 'Η': 'H', 'Ι': 'I',
+	'Κ': 'K', 'Μ': 'M', 'Ν': 'N', 'Ο': 'O', 'Ρ': 'P', 'Τ': 'T', 'Υ': 'Y',
+	'Χ': 'X',
+}
+
+// CanonicalRegistration returns the form under which registrations are
+// compared: compatibility characters such as full-width letters are
+// decomposed, accents dropped, letters upper-cased and look-alikes from
+// other scripts replaced by their Latin letter. Spaces, dashes and any other
+// separator are removed, so "ab-123-cd", "AB123CD" and "AB 123 CD" are the
+// same registration.
+func CanonicalRegistration(registration string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(registration) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		r = unicode.ToUpper(r)
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// DisplayRegistration returns a registration as shown to users: as entered,
+// without leading, trailing or repeated spaces.
+func DisplayRegistration(registration string) string {
+	return strings.Join(strings.Fields(registration), " ")
+}
+
+// BeforeSave keeps the canonical registration in step with the registration.
+func (c *Car) BeforeSave(tx *gorm.DB) error {
+	c.CanonicalRegistration = CanonicalRegistration(c.Registration)
+	return nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Rental records a car leaving the parking lot and, once returned, the
+// odometer readings taken at both ends.
+type Rental struct {
+	ID                   uint           `json:"id" gorm:"primarykey"`
+	TenantID             string         `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	CarID                uint           `json:"car_id" gorm:"index;not null"`
+	Car                  *Car           `json:"car,omitempty"`
+	CustomerID           *uint          `json:"customer_id" gorm:"index"`
+	Customer             *Customer      `json:"customer,omitempty"`
+	StartedAt            time.Time      `json:"started_at"`
+	EndedAt              *time.Time     `json:"ended_at" gorm:"index"`
+	PlannedEndAt         *time.Time     `json:"planned_end_at"`
+	StartOdometer        float64        `json:"start_odometer"`
+	EndOdometer          *float64       `json:"end_odometer"`
+	Distance             float64        `json:"distance"`
+	DailyRate            float64        `json:"daily_rate"`
+	YoungDriverSurcharge float64        `json:"young_driver_surcharge"`
+	Deposit              float64        `json:"deposit"`
+	AdditionalDrivers    []RentalDriver `json:"additional_drivers,omitempty"`
+	PlannedAmount        float64        `json:"planned_amount"`
+	Amount               float64        `json:"amount"`
+	Adjustment           float64        `json:"adjustment"`
+	Overdue              bool           `json:"overdue" gorm:"index"`
+	OverdueAt            *time.Time     `json:"overdue_at,omitempty"`
+	LateFee              float64        `json:"late_fee"`
+	NeedsReview          bool           `json:"needs_review" gorm:"index"`
+	ReviewReason         string         `json:"review_reason,omitempty"`
+	ReviewedAt           *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewNote           string         `json:"review_note,omitempty"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+// RentalDriver is a driver named on a rental agreement besides the customer.
+type RentalDriver struct {
+	ID         uint   `json:"-" gorm:"primarykey"`
+	TenantID   string `json:"-" gorm:"size:64;not null;default:default;index"`
+	RentalID   uint   `json:"-" gorm:"index;not null"`
+	CustomerID uint   `json:"customer_id" gorm:"index;not null"`
+}
+
+// OdometerSource tells where an odometer reading comes from.
+type OdometerSource string
+
+const (
+	OdometerAdded    OdometerSource = "added"
+	OdometerCheckout OdometerSource = "checkout"
+	OdometerReturn   OdometerSource = "return"
+)
+
+// OdometerReading is an absolute odometer value recorded for a car.
+type OdometerReading struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	TenantID  string         `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	CarID     uint           `json:"car_id" gorm:"index;not null"`
+	RentalID  *uint          `json:"rental_id"`
+	Reading   float64        `json:"reading"`
+	Source    OdometerSource `json:"source" gorm:"size:16"`
+	CreatedAt time.Time      `json:"created_at"`
+}
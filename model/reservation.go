@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Reservation books a car for a customer over a future period.
+type Reservation struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	TenantID    string     `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	CarID       uint       `json:"car_id" gorm:"index;not null"`
+	CustomerID  *uint      `json:"customer_id" gorm:"index"`
+	StartsAt    time.Time  `json:"starts_at" gorm:"index"`
+	EndsAt      time.Time  `json:"ends_at" gorm:"index"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	RemindedAt  *time.Time `json:"reminded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
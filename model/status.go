@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CarStatus is the lifecycle state of a car in the fleet.
+type CarStatus string
+
+const (
+	StatusAvailable   CarStatus = "available"
+	StatusReserved    CarStatus = "reserved"
+	StatusRented      CarStatus = "rented"
+	StatusMaintenance CarStatus = "maintenance"
+	StatusInTransit   CarStatus = "in_transit"
+	StatusRetired     CarStatus = "retired"
+	StatusLost        CarStatus = "lost"
+)
+
+// carTransitions lists, for every status, the statuses a car may move to.
+// Retired is terminal.
+var carTransitions = map[CarStatus][]CarStatus{
+	StatusAvailable:   {StatusReserved, StatusRented, StatusMaintenance, StatusInTransit, StatusRetired, StatusLost},
+	StatusReserved:    {StatusAvailable, StatusRented, StatusLost},
+	StatusRented:      {StatusAvailable, StatusMaintenance, StatusLost},
+	StatusMaintenance: {StatusAvailable, StatusInTransit, StatusRetired},
+	StatusInTransit:   {StatusAvailable, StatusMaintenance, StatusLost},
+	StatusLost:        {StatusAvailable, StatusRetired},
+	StatusRetired:     {},
+}
+
+// Valid reports whether the status is one of the known lifecycle states.
+func (s CarStatus) Valid() bool {
+	_, ok := carTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether the transition table allows moving from s to next.
+func (s CarStatus) CanTransitionTo(next CarStatus) bool {
+	for _, allowed := range carTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CarStatusTransition records a single change of a car's status.
+type CarStatusTransition struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	TenantID  string    `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	CarID     uint      `json:"car_id" gorm:"index;not null"`
+	From      CarStatus `json:"from" gorm:"size:16"`
+	To        CarStatus `json:"to" gorm:"size:16;not null"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AfterFind derives the Available flag from the car status.
+func (c *Car) AfterFind(tx *gorm.DB) error {
+	c.Available = c.Status == StatusAvailable
+	return nil
+}
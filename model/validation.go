@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// firstModelYear is the oldest model year accepted for a car in the fleet.
+const firstModelYear = 1950
+
+// ValidateCar checks and normalizes the descriptive fields of a car. It
+// returns an error message suitable for the client, or an empty string.
+func ValidateCar(car *Car) string {
+	if car.Mileage < 0 {
+		return "Mileage parameter must be positive"
+	}
+	car.Registration = DisplayRegistration(car.Registration)
+	canonical := CanonicalRegistration(car.Registration)
+	if car.CarModel == "" || canonical == "" {
+		return "Car model and registration are required"
+	}
+	if len([]rune(canonical)) > MaxRegistrationLength {
+		return "Registration must have at most " + strconv.Itoa(MaxRegistrationLength) + " letters and digits"
+	}
+	if car.Category != "" {
+		category, err := NormalizeCategory(car.Category, false)
+		if err != nil {
+			return "Category must be a valid ACRISS code"
+		}
+		car.Category = category
+	}
+	if car.VIN != "" {
+		vin, err := NormalizeVIN(car.VIN)
+		if err != nil {
+			return "VIN must be 17 characters with a valid check digit"
+		}
+		car.VIN = vin
+	}
+	if car.Year != 0 && (car.Year < firstModelYear || car.Year > time.Now().Year()+1) {
+		return "Year must be between " + strconv.Itoa(firstModelYear) + " and next year"
+	}
+	// Like the year, a number of seats of 0 means it is not known.
+	if car.Seats != 0 && (car.Seats < 1 || car.Seats > 60) {
+		return "Seats must be between 1 and 60, or 0 when unknown"
+	}
+	if car.Transmission != "" && !car.Transmission.Valid() {
+		return "Transmission must be manual or automatic"
+	}
+	if car.FuelType != "" && !car.FuelType.Valid() {
+		return "Fuel type must be one of petrol, diesel, electric, hybrid, plugin_hybrid, lpg or hydrogen"
+	}
+	if car.DailyRate < 0 {
+		return "Daily rate must be positive"
+	}
+	if car.PurchasePrice < 0 {
+		return "Purchase price must be positive"
+	}
+	if car.PurchaseDate != nil && car.PurchaseDate.After(time.Now()) {
+		return "Purchase date cannot be in the future"
+	}
+
+	return ""
+}
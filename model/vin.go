@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidVIN is returned when a vehicle identification number is malformed
+// or its check digit does not match.
+var ErrInvalidVIN = errors.New("VIN must be 17 characters with a valid check digit")
+
+// vinWeights are the ISO 3779 position weights used to compute the check digit.
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// vinValue transliterates a VIN character to its numeric value. The letters
+// I, O and Q are not allowed in a VIN and yield false.
+func vinValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'N':
+		return int(c-'J') + 1, true
+	case c == 'P':
+		return 7, true
+	case c == 'R':
+		return 9, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	default:
+		return 0, false
+	}
+}
+
+// NormalizeVIN upper-cases a VIN and validates its alphabet and check digit.
+func NormalizeVIN(vin string) (string, error) {
+	vin = strings.ToUpper(strings.TrimSpace(vin))
+	if len(vin) != len(vinWeights) {
+		return "", ErrInvalidVIN
+	}
+
+	sum := 0
+	for i := 0; i < len(vin); i++ {
+		value, ok := vinValue(vin[i])
+		if !ok {
+			return "", ErrInvalidVIN
+		}
+		sum += value * vinWeights[i]
+	}
+
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+	if vin[8] != check {
+		return "", ErrInvalidVIN
+	}
+
+	return vin, nil
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Event types published to webhook subscribers.
+const (
+	EventCarAdded         = "car.added"
+	EventCarRented        = "car.rented"
+	EventCarReturned      = "car.returned"
+	EventCarStatusChanged = "car.status_changed"
+	EventCarUpdated       = "car.updated"
+	EventCarDeleted       = "car.deleted"
+	EventRentalOverdue    = "rental.overdue"
+)
+
+// EventTypes lists every event type a webhook can subscribe to.
+var EventTypes = []string{EventCarAdded, EventCarRented, EventCarReturned, EventCarStatusChanged, EventCarUpdated, EventCarDeleted, EventRentalOverdue}
+
+// OutboxEvent is a fleet change written in the same transaction as the change
+// itself, so that it is published even if the process stops right after.
+type OutboxEvent struct {
+	ID           uint       `json:"id" gorm:"primarykey"`
+	TenantID     string     `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	Type         string     `json:"type" gorm:"size:64;index"`
+	Payload      string     `json:"payload" gorm:"type:text"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DispatchedAt *time.Time `json:"dispatched_at" gorm:"index"`
+}
+
+// WebhookSubscription is an endpoint notified of the given event types.
+type WebhookSubscription struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	TenantID  string    `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	URL       string    `json:"url" gorm:"not null"`
+	Events    []string  `json:"events" gorm:"serializer:json;type:text"`
+	Secret    string    `json:"secret,omitempty" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the subscription wants events of the given type.
+func (s WebhookSubscription) Subscribes(eventType string) bool {
+	for _, event := range s.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+// WebhookDelivery tracks the attempts to send one event to one subscription.
+// Deliveries that exhaust their attempts are dead and kept for inspection.
+type WebhookDelivery struct {
+	ID             uint           `json:"id" gorm:"primarykey"`
+	TenantID       string         `json:"tenant_id" gorm:"size:64;not null;default:default;index"`
+	SubscriptionID uint           `json:"subscription_id" gorm:"index;not null"`
+	EventID        uint           `json:"event_id" gorm:"index;not null"`
+	Event          *OutboxEvent   `json:"event,omitempty"`
+	Status         DeliveryStatus `json:"status" gorm:"size:16;index"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int            `json:"last_status_code"`
+	LastError      string         `json:"last_error"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
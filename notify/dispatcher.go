@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// batchSize bounds the notifications handled by one RunOnce.
+const batchSize = 100
+
+// ErrNoNotifier is recorded on notifications of a channel without notifier.
+var ErrNoNotifier = errors.New("no notifier for the channel")
+
+// Dispatcher sends the due notifications of the outbox, retrying failures
+// with exponential backoff until MaxAttempts, after which a notification is
+// dead.
+type Dispatcher struct {
+	DB          *gorm.DB
+	Logger      *slog.Logger
+	Notifiers   map[model.NotificationChannel]Notifier
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// SendTimeout bounds one attempt; notifications are leased for it.
+	SendTimeout time.Duration
+}
+
+// NewDispatcher creates a dispatcher sending through the notifiers, with
+// default retry settings.
+func NewDispatcher(db *gorm.DB, logger *slog.Logger, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		DB:          db,
+		Logger:      logger,
+		Notifiers:   map[model.NotificationChannel]Notifier{},
+		MaxAttempts: 6,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  6 * time.Hour,
+		SendTimeout: 30 * time.Second,
+	}
+	for _, notifier := range notifiers {
+		d.Notifiers[notifier.Channel()] = notifier
+	}
+	return d
+}
+
+// Run calls RunOnce every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.RunOnce(ctx); err != nil {
+			d.Logger.ErrorContext(ctx, "notification dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends the pending notifications whose next attempt is due.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	var notifications []model.Notification
+	err := d.DB.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", model.NotificationPending, time.Now()).
+		Order("next_attempt_at").Limit(batchSize).Find(&notifications).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		if err := d.attempt(ctx, &notifications[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt sends one notification and records its outcome.
+func (d *Dispatcher) attempt(ctx context.Context, notification *model.Notification) error {
+	// Lease the notification so another replica does not send it concurrently.
+	lease := time.Now().Add(d.SendTimeout + time.Minute)
+	result := d.DB.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", notification.ID, model.NotificationPending, notification.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	sendErr := d.send(ctx, notification)
+
+	now := time.Now()
+	notification.Attempts++
+	notification.LastError = ""
+	switch {
+	case sendErr == nil:
+		notification.Status = model.NotificationSent
+		notification.SentAt = &now
+	case notification.Attempts >= d.MaxAttempts:
+		notification.Status = model.NotificationDead
+		notification.LastError = sendErr.Error()
+		d.Logger.WarnContext(ctx, "notification is dead", "notification_id", notification.ID,
+			"channel", notification.Channel, "error", sendErr)
+	default:
+		notification.LastError = sendErr.Error()
+		notification.NextAttemptAt = now.Add(d.backoff(notification.Attempts))
+	}
+
+	return d.DB.WithContext(ctx).Save(notification).Error
+}
+
+// send hands the notification to the notifier of its channel.
+func (d *Dispatcher) send(ctx context.Context, notification *model.Notification) error {
+	notifier, ok := d.Notifiers[notification.Channel]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrNoNotifier, notification.Channel)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.SendTimeout)
+	defer cancel()
+	return notifier.Send(ctx, Message{To: notification.Recipient, Subject: notification.Subject, Body: notification.Body})
+}
+
+// backoff doubles the wait after every failed attempt, up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,24 @@
+// Package notify tells customers about their bookings, pickups, late returns
+// and invoices. Notifications are rendered from localized templates into an
+// outbox table, in the same transaction as the change they describe when
+// there is one, then sent by email or SMS with retries.
+package notify
+
+import (
+	"context"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// Message is a rendered notification, ready to be sent.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier sends the messages of one channel.
+type Notifier interface {
+	Channel() model.NotificationChannel
+	Send(ctx context.Context, message Message) error
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Outbox queues notifications for the Dispatcher.
+type Outbox struct {
+	DB        *gorm.DB
+	Templates *Templates
+	// Channels are the channels notifications are queued on; customers are
+	// reached on each of them they have an address for. Nothing is queued
+	// when it is empty.
+	Channels []model.NotificationChannel
+}
+
+// NewOutbox returns an outbox with the default templates and no channel.
+func NewOutbox(db *gorm.DB) *Outbox {
+	return &Outbox{DB: db, Templates: DefaultTemplates()}
+}
+
+// Enqueue renders the notification of kind for the customer, in the
+// customer's locale, and queues it inside tx on every enabled channel.
+// data.Customer is loaded by Enqueue, and so is data.Car when it is missing.
+func (o *Outbox) Enqueue(tx *gorm.DB, kind model.NotificationKind, customerID uint, data Data) error {
+	if len(o.Channels) == 0 {
+		return nil
+	}
+	if err := tx.First(&data.Customer, customerID).Error; err != nil {
+		return err
+	}
+	if data.Car.ID == 0 {
+		var carID uint
+		if data.Rental != nil {
+			carID = data.Rental.CarID
+		} else if data.Reservation != nil {
+			carID = data.Reservation.CarID
+		}
+		// Deleted cars are still named in the notifications of their rentals.
+		if err := tx.Unscoped().First(&data.Car, carID).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, channel := range o.Channels {
+		recipient := data.Customer.Email
+		if channel == model.ChannelSMS {
+			recipient = data.Customer.Phone
+		}
+		if recipient == "" {
+			continue
+		}
+
+		message, locale, err := o.Templates.Render(data.Customer.Locale, kind, channel, data)
+		if err != nil {
+			return err
+		}
+
+		notification := model.Notification{
+			CustomerID:    customerID,
+			Kind:          kind,
+			Channel:       channel,
+			Recipient:     recipient,
+			Locale:        locale,
+			Subject:       message.Subject,
+			Body:          message.Body,
+			Status:        model.NotificationPending,
+			NextAttemptAt: now,
+		}
+		if data.Rental != nil {
+			notification.RentalID = &data.Rental.ID
+		}
+		if data.Reservation != nil {
+			notification.ReservationID = &data.Reservation.ID
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Filter selects notifications. Zero values are ignored.
+type Filter struct {
+	Status     model.NotificationStatus
+	CustomerID uint
+	RentalID   uint
+}
+
+// List returns the notifications matching the filter, most recent first.
+func (o *Outbox) List(ctx context.Context, filter Filter) ([]model.Notification, error) {
+	query := o.DB.WithContext(ctx).Order("id DESC").Limit(batchSize)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CustomerID > 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.RentalID > 0 {
+		query = query.Where("rental_id = ?", filter.RentalID)
+	}
+
+	var notifications []model.Notification
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+// Retry requeues a notification for immediate sending with a fresh set of
+// attempts. Sent notifications are sent again.
+func (o *Outbox) Retry(ctx context.Context, id uint) (model.Notification, error) {
+	var notification model.Notification
+	err := o.DB.WithContext(ctx).First(&notification, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notification, ErrNotificationNotFound
+	}
+	if err != nil {
+		return notification, err
+	}
+
+	notification.Status = model.NotificationPending
+	notification.Attempts = 0
+	notification.NextAttemptAt = time.Now()
+	notification.LastError = ""
+	notification.SentAt = nil
+	err = o.DB.WithContext(ctx).Save(&notification).Error
+	return notification, err
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// Sink writes the messages of a channel to a console or a file instead of
+// sending them, for local development and tests.
+type Sink struct {
+	channel model.NotificationChannel
+
+	mu  *sync.Mutex
+	out io.Writer
+}
+
+// NewSink returns a sink writing the messages of channel to out. Sinks
+// sharing out may be used together.
+func NewSink(channel model.NotificationChannel, out io.Writer) *Sink {
+	return &Sink{channel: channel, mu: &sync.Mutex{}, out: out}
+}
+
+// NewSinks returns sinks for every channel, writing to the console when path
+// is "console" and appending to the file at path otherwise.
+func NewSinks(path string) ([]Notifier, error) {
+	var out io.Writer = os.Stdout
+	if path != "console" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	mu := &sync.Mutex{}
+	return []Notifier{
+		&Sink{channel: model.ChannelEmail, mu: mu, out: out},
+		&Sink{channel: model.ChannelSMS, mu: mu, out: out},
+	}, nil
+}
+
+func (s *Sink) Channel() model.NotificationChannel { return s.channel }
+
+// Send writes the message with its channel, recipient and time.
+func (s *Sink) Send(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.out, "--- %s to %s at %s\n", s.channel, message.To, time.Now().Format(time.RFC3339))
+	if err == nil && message.Subject != "" {
+		_, err = fmt.Fprintf(s.out, "Subject: %s\n", message.Subject)
+	}
+	if err == nil {
+		_, err = fmt.Fprintf(s.out, "\n%s\n\n", message.Body)
+	}
+	return err
+}
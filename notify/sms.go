@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// SMS sends notifications as text messages through an HTTP gateway, which
+// receives a JSON {"from", "to", "body"} POST.
+type SMS struct {
+	URL    string
+	Token  string
+	From   string
+	Client *http.Client
+}
+
+// NewSMS returns an SMS notifier posting to the gateway at url with the
+// bearer token.
+func NewSMS(url, token, from string) *SMS {
+	return &SMS{URL: url, Token: token, From: from, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *SMS) Channel() model.NotificationChannel { return model.ChannelSMS }
+
+// Send posts the message to the gateway. Any response other than 2xx is a
+// failure.
+func (s *SMS) Send(ctx context.Context, message Message) error {
+	body, err := json.Marshal(map[string]string{"from": s.From, "to": message.To, "body": message.Body})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway answered %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// SMTP sends notifications by email through a mail server.
+type SMTP struct {
+	// Addr is the host:port of the server.
+	Addr string
+	// From is the sender address.
+	From string
+	// Auth authenticates to the server; nil for servers without
+	// authentication, such as a local relay.
+	Auth smtp.Auth
+}
+
+// NewSMTP returns an SMTP notifier, authenticating with PLAIN when a username
+// is given.
+func NewSMTP(addr, from, username, password string) *SMTP {
+	s := &SMTP{Addr: addr, From: from}
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTP) Channel() model.NotificationChannel { return model.ChannelEmail }
+
+// Send delivers the message. net/smtp takes no context: ctx is only checked
+// before connecting.
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, []byte(b.String()))
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+var ErrNoTemplate = errors.New("no notification template")
+
+//go:embed templates
+var embedded embed.FS
+
+// Data is what notification templates are rendered with.
+type Data struct {
+	Customer    model.Customer
+	Car         model.Car
+	Rental      *model.Rental
+	Reservation *model.Reservation
+}
+
+// locales formats dates and amounts in the languages of the templates.
+// Unknown languages use the English formats.
+var locales = map[string]struct {
+	date     string
+	currency string
+}{
+	"en": {"Jan 2, 2006 at 15:04", "%.2f"},
+	"fr": {"02/01/2006 à 15h04", "%.2f"},
+}
+
+// Templates renders notifications from one template file per locale and
+// kind, named <locale>/<kind>.tmpl. A file defines a "subject" and an
+// "email" template for emails and an "sms" template for text messages.
+type Templates struct {
+	// Default is the locale of customers without one, or with a locale that
+	// has no templates.
+	Default string
+
+	byLocale map[string]map[model.NotificationKind]*template.Template
+}
+
+// DefaultTemplates returns the templates shipped with the API.
+func DefaultTemplates() *Templates {
+	sub, _ := fs.Sub(embedded, "templates")
+	templates, err := LoadTemplates(sub)
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// LoadTemplates parses the templates of fsys, one directory per locale.
+func LoadTemplates(fsys fs.FS) (*Templates, error) {
+	t := &Templates{Default: "en", byLocale: map[string]map[model.NotificationKind]*template.Template{}}
+
+	files, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		locale := path.Dir(file)
+		kind := model.NotificationKind(strings.TrimSuffix(path.Base(file), ".tmpl"))
+
+		format, ok := locales[strings.SplitN(locale, "-", 2)[0]]
+		if !ok {
+			format = locales["en"]
+		}
+		funcs := template.FuncMap{
+			"date":  func(t interface{}) string { return formatDate(t, format.date) },
+			"money": func(amount float64) string { return fmt.Sprintf(format.currency, amount) },
+		}
+
+		parsed, err := template.New(path.Base(file)).Funcs(funcs).ParseFS(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		if t.byLocale[locale] == nil {
+			t.byLocale[locale] = map[model.NotificationKind]*template.Template{}
+		}
+		t.byLocale[locale][kind] = parsed
+	}
+
+	return t, nil
+}
+
+// formatDate formats a time.Time or a *time.Time, nil being empty.
+func formatDate(value interface{}, layout string) string {
+	switch t := value.(type) {
+	case time.Time:
+		return t.Format(layout)
+	case *time.Time:
+		if t != nil {
+			return t.Format(layout)
+		}
+	}
+	return ""
+}
+
+// lookup returns the template of kind for locale, falling back to its
+// language, then to the default locale.
+func (t *Templates) lookup(locale string, kind model.NotificationKind) (*template.Template, string, error) {
+	language, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, language, t.Default} {
+		if tmpl, ok := t.byLocale[candidate][kind]; ok {
+			return tmpl, candidate, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%w for %s", ErrNoTemplate, kind)
+}
+
+// Render renders the notification of kind sent on channel, and returns it
+// with the locale used. Text messages have no subject.
+func (t *Templates) Render(locale string, kind model.NotificationKind, channel model.NotificationChannel, data Data) (Message, string, error) {
+	tmpl, used, err := t.lookup(strings.ToLower(locale), kind)
+	if err != nil {
+		return Message{}, "", err
+	}
+
+	execute := func(name string) (string, error) {
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, name, data); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(b.String()), nil
+	}
+
+	var message Message
+	if channel == model.ChannelEmail {
+		if message.Subject, err = execute("subject"); err != nil {
+			return Message{}, "", err
+		}
+	}
+	if message.Body, err = execute(string(channel)); err != nil {
+		return Message{}, "", err
+	}
+	return message, used, nil
+}
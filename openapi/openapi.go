@@ -0,0 +1,10 @@
+// Package openapi embeds the OpenAPI 3 document describing the REST API.
+package openapi
+
+import _ "embed"
+
+// Spec is the OpenAPI 3 document, served at /openapi.json. Every route
+// registered by routes.SetupRoutes must be described in it.
+//
+//go:embed openapi.json
+var Spec []byte
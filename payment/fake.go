@@ -0,0 +1,131 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DeclinedMethod is a payment method the fake provider always declines.
+const DeclinedMethod = "tok_declined"
+
+// Fake is an in-process provider for tests and development: it moves no
+// money but keeps the state of every authorization and capture, and refuses
+// the operations a real provider would.
+type Fake struct {
+	// Limit declines the authorizations above it; zero for no limit.
+	Limit float64
+	// CaptureFailures fails that many of the next captures, as a provider
+	// that cannot be reached would.
+	CaptureFailures int
+
+	mu       sync.Mutex
+	next     int
+	holds    map[string]*fakeHold
+	captures map[string]*fakeCapture
+}
+
+type fakeHold struct {
+	amount float64
+	closed bool
+}
+
+type fakeCapture struct {
+	amount   float64
+	refunded float64
+}
+
+// NewFake returns a fake provider without a limit.
+func NewFake() *Fake {
+	return &Fake{holds: map[string]*fakeHold{}, captures: map[string]*fakeCapture{}}
+}
+
+func (f *Fake) Name() string { return "fake" }
+
+// reference returns a new reference with the given prefix.
+func (f *Fake) reference(prefix string) string {
+	f.next++
+	return fmt.Sprintf("%s_%06d", prefix, f.next)
+}
+
+func (f *Fake) Authorize(ctx context.Context, method string, amount float64) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if method == DeclinedMethod {
+		return "", fmt.Errorf("%w: card declined", ErrDeclined)
+	}
+	if f.Limit > 0 && amount > f.Limit {
+		return "", fmt.Errorf("%w: insufficient funds", ErrDeclined)
+	}
+
+	reference := f.reference("auth")
+	f.holds[reference] = &fakeHold{amount: amount}
+	return reference, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, authorization string, amount float64) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.CaptureFailures > 0 {
+		f.CaptureFailures--
+		return "", errors.New("payment provider unavailable")
+	}
+
+	hold, ok := f.holds[authorization]
+	if !ok || hold.closed {
+		return "", ErrUnknownReference
+	}
+	if amount > hold.amount {
+		return "", ErrExceedsAuthorization
+	}
+
+	hold.closed = true
+	reference := f.reference("cap")
+	f.captures[reference] = &fakeCapture{amount: amount}
+	return reference, nil
+}
+
+func (f *Fake) Release(ctx context.Context, authorization string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hold, ok := f.holds[authorization]
+	if !ok || hold.closed {
+		return ErrUnknownReference
+	}
+	hold.closed = true
+	return nil
+}
+
+func (f *Fake) Refund(ctx context.Context, capture string, amount float64) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	captured, ok := f.captures[capture]
+	if !ok {
+		return "", ErrUnknownReference
+	}
+	if captured.refunded+amount > captured.amount {
+		return "", ErrExceedsCapture
+	}
+
+	captured.refunded += amount
+	return f.reference("ref"), nil
+}
+
+// Held returns the amount still held by the open authorizations.
+func (f *Fake) Held() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := 0.0
+	for _, hold := range f.holds {
+		if !hold.closed {
+			total += hold.amount
+		}
+	}
+	return total
+}
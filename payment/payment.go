@@ -0,0 +1,38 @@
+// Package payment moves the money of rentals through a payment provider: a
+// deposit is authorized on the customer's payment method when a car is
+// rented, the final amount is captured from it when the car is returned,
+// releasing the remainder, and captures can be refunded.
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrDeclined is wrapped by the errors of authorizations the provider
+	// refused, with its reason.
+	ErrDeclined             = errors.New("payment declined")
+	ErrUnknownReference     = errors.New("unknown payment reference")
+	ErrExceedsAuthorization = errors.New("amount exceeds the authorization")
+	ErrExceedsCapture       = errors.New("amount exceeds the capture")
+)
+
+// Provider is a payment service provider. References are the provider's own
+// ids for an authorization, capture or refund.
+type Provider interface {
+	// Name identifies the provider in payment records.
+	Name() string
+	// Authorize holds amount on a payment method and returns the reference
+	// of the authorization.
+	Authorize(ctx context.Context, method string, amount float64) (string, error)
+	// Capture takes amount, at most the authorized amount, and releases the
+	// remainder of the authorization. It returns the reference of the
+	// capture.
+	Capture(ctx context.Context, authorization string, amount float64) (string, error)
+	// Release cancels an authorization without taking anything.
+	Release(ctx context.Context, authorization string) error
+	// Refund gives back amount of a capture and returns the reference of the
+	// refund.
+	Refund(ctx context.Context, capture string, amount float64) (string, error)
+}
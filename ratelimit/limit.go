@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests can be sent at once, and the
+// bucket refills with one token every Period / Burst.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.Burst <= 0 || l.Period <= 0
+}
+
+// interval is the time needed to earn one token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Burst)
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return strconv.Itoa(l.Burst) + "/" + l.Period.String()
+}
+
+// units are the periods accepted after the slash of a limit.
+var units = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// ParseLimit parses a limit written as requests per period, e.g. 60/m, 10/s
+// or 1000/1h. "off" disables the limit.
+func ParseLimit(text string) (Limit, error) {
+	text = strings.TrimSpace(text)
+	if text == "off" {
+		return Limit{}, nil
+	}
+
+	count, per, ok := strings.Cut(text, "/")
+	burst, err := strconv.Atoi(count)
+	if !ok || err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected requests/period", text)
+	}
+	period, ok := units[per]
+	if !ok {
+		if period, err = time.ParseDuration(per); err != nil || period <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit period %q", per)
+		}
+	}
+	return Limit{Burst: burst, Period: period}, nil
+}
+
+// ParseRouteLimits parses a comma separated list of route limits, each
+// written as "METHOD /path/template=limit", e.g.
+// "POST /cars=30/m,GET /cars=300/m,GET /healthz=off".
+func ParseRouteLimits(text string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(text, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid route limit %q, expected \"METHOD /path=limit\"", entry)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.ToUpper(method)+" "+path] = limit
+	}
+	return limits, nil
+}
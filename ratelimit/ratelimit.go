@@ -0,0 +1,134 @@
+// Package ratelimit limits the request rate of each client with token
+// buckets, with limits set per route.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHeader identifies the client of a request. Requests without one of
+// the configured keys are limited by client IP.
+const APIKeyHeader = "X-API-Key"
+
+// Limiter is the rate limiting middleware of the router.
+type Limiter struct {
+	Store  Store
+	Logger *slog.Logger
+	// Default applies to the routes missing from Routes. The zero Limit
+	// lets every request through.
+	Default Limit
+	// Routes holds the limits of routes, keyed by method and path template
+	// ("POST /cars").
+	Routes map[string]Limit
+	// TrustProxy identifies clients by the last X-Forwarded-For address,
+	// the one added by the load balancer, instead of the peer address.
+	TrustProxy bool
+	// APIKeys holds the API keys whose requests are limited by key rather
+	// than by address. Other keys are ignored, so that a client cannot get
+	// a fresh bucket by sending a new key.
+	APIKeys map[string]bool
+}
+
+// New creates a limiter without any limit.
+func New(store Store, logger *slog.Logger) *Limiter {
+	return &Limiter{Store: store, Logger: logger, Routes: map[string]Limit{}, APIKeys: map[string]bool{}}
+}
+
+// ParseAPIKeys parses a comma-separated list of API keys.
+func ParseAPIKeys(text string) map[string]bool {
+	keys := map[string]bool{}
+	for _, key := range strings.Split(text, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// route returns the method and path template of the route of a request.
+func route(r *http.Request) string {
+	path := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			path = template
+		}
+	}
+	return r.Method + " " + path
+}
+
+// client identifies the client of a request by its API key when it is one
+// of APIKeys, hashed so that keys are not kept in the store, or by its IP
+// address.
+func (l *Limiter) client(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" && l.APIKeys[key] {
+		hash := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(hash[:16])
+	}
+
+	if l.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addresses := strings.Split(forwarded, ",")
+			return "ip:" + strings.TrimSpace(addresses[len(addresses)-1])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds rounds a wait up to whole seconds, as used by the headers.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
+
+// Middleware refuses the requests of clients which exceeded the limit of the
+// route with 429 Too Many Requests. Every limited response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := route(r)
+		limit, ok := l.Routes[name]
+		if !ok {
+			limit = l.Default
+		}
+		if limit.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := l.Store.Take(r.Context(), name+" "+l.client(r), limit, time.Now())
+		if err != nil {
+			// An unavailable store must not take the API down with it.
+			l.Logger.WarnContext(r.Context(), "Rate limit store failed", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+		w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+seconds(limit.Period))
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", seconds(result.RetryAfter))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Rate limit exceeded, retry in " + seconds(result.RetryAfter) + " seconds"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	// Allowed reports whether a token was available.
+	Allowed bool
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// RetryAfter is the wait before the next token, when none was left.
+	RetryAfter time.Duration
+	// Reset is the wait before the bucket is full again.
+	Reset time.Duration
+}
+
+// Store holds the token buckets. MemoryStore keeps them in the process;
+// instances of the API behind a load balancer can share their buckets
+// through another implementation backed by a shared cache.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// MemoryStore is a Store local to the process.
+type MemoryStore struct {
+	mu sync.Mutex
+	// buckets holds, for each key, the time at which its bucket will be full
+	// again. Storing a single time per bucket is enough to know how many
+	// tokens it holds at any moment.
+	buckets map[string]time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]time.Time{}}
+}
+
+// Take removes a token from the bucket of key, if one is left.
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	full := m.buckets[key]
+	result := take(&full, limit, now)
+	m.buckets[key] = full
+	return result, nil
+}
+
+// take applies a request to a bucket that is full at the given time,
+// moving that time forward by one token if the request is allowed.
+func take(full *time.Time, limit Limit, now time.Time) Result {
+	interval := limit.interval()
+	if full.Before(now) {
+		*full = now
+	}
+
+	// The bucket holds the tokens not yet spent within the period.
+	next := full.Add(interval)
+	if next.Sub(now) > limit.Period {
+		return Result{RetryAfter: next.Sub(now) - limit.Period, Reset: full.Sub(now)}
+	}
+
+	*full = next
+	reset := next.Sub(now)
+	remaining := int((limit.Period - reset) / interval)
+	return Result{Allowed: true, Remaining: remaining, Reset: reset}
+}
+
+// Purge drops the buckets which are full again, returning how many were
+// removed. They are recreated full on the next request.
+func (m *MemoryStore) Purge(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for key, full := range m.buckets {
+		if !full.After(now) {
+			delete(m.buckets, key)
+			count++
+		}
+	}
+	return count
+}
+
+// Run purges the store at each interval until ctx is canceled.
+func (m *MemoryStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.Purge(now)
+		}
+	}
+}
@@ -1,16 +1,62 @@
 package routes
 
 import (
+	"github.com/abdeel07/backend-go-cars/graphqlapi"
 	"github.com/abdeel07/backend-go-cars/handlers"
+	"github.com/abdeel07/backend-go-cars/logging"
 	"github.com/abdeel07/backend-go-cars/server"
 	"github.com/gorilla/mux"
 )
 
+// public lists the routes served without a tenant. /metrics covers every
+// tenant, and requires the admin token instead.
+var public = map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true, "/openapi.json": true}
+
 func SetupRoutes(router *mux.Router, s *server.Server) {
+	router.Use(logging.RequestID, logging.AccessLog(s.Logger), s.Metrics.Middleware,
+		s.Tenants.Middleware(public), s.RateLimit.Middleware, s.Idempotency.Middleware)
+	router.HandleFunc("/metrics", handlers.RequireAdmin(s, s.Metrics.Handler().ServeHTTP)).Methods("GET")
+	router.HandleFunc("/healthz", handlers.Healthz(s)).Methods("GET")
+	router.HandleFunc("/readyz", handlers.Readyz(s)).Methods("GET")
+	router.HandleFunc("/openapi.json", handlers.OpenAPISpec(s)).Methods("GET")
+
 	router.HandleFunc("/cars", handlers.ListCars(s)).Methods("GET")
 	router.HandleFunc("/cars", handlers.AddCar(s)).Methods("POST")
+	router.HandleFunc("/cars/search", handlers.SearchCars(s)).Methods("GET")
 	router.HandleFunc("/cars/{registration}", handlers.GetCar(s)).Methods("GET")
+	router.HandleFunc("/cars/{registration}", handlers.UpdateCar(s)).Methods("PATCH")
 	router.HandleFunc("/cars/{registration}", handlers.DeleteCar(s)).Methods("DELETE")
 	router.HandleFunc("/cars/{registration}/rentals", handlers.RentCar(s)).Methods("PUT")
+	router.HandleFunc("/rentals", handlers.RentByCategory(s)).Methods("POST")
 	router.HandleFunc("/cars/{registration}/returns", handlers.ReturnCar(s)).Methods("PUT")
+	router.HandleFunc("/cars/{registration}/status", handlers.SetCarStatus(s)).Methods("PUT")
+	router.HandleFunc("/cars/{registration}/transitions", handlers.CarStatusHistory(s)).Methods("GET")
+	router.HandleFunc("/cars/{registration}/odometer", handlers.OdometerHistory(s)).Methods("GET")
+	router.HandleFunc("/cars/{registration}/reservations", handlers.ListReservations(s)).Methods("GET")
+	router.HandleFunc("/cars/{registration}/reservations", handlers.CreateReservation(s)).Methods("POST")
+	router.HandleFunc("/reservations/{id}", handlers.CancelReservation(s)).Methods("DELETE")
+	router.HandleFunc("/rentals/reviews", handlers.PendingReviews(s)).Methods("GET")
+	router.HandleFunc("/rentals/{id}/review", handlers.ReviewRental(s)).Methods("PUT")
+	router.HandleFunc("/rentals/{id}/payments", handlers.RentalPayments(s)).Methods("GET")
+	router.HandleFunc("/rentals/{id}/refunds", handlers.RefundRental(s)).Methods("POST")
+	router.HandleFunc("/rentals/{id}/extend", handlers.ExtendRental(s)).Methods("POST")
+	router.HandleFunc("/reports/utilization", handlers.UtilizationReport(s)).Methods("GET")
+	router.HandleFunc("/reports/rentals", handlers.RentalReport(s)).Methods("GET")
+	router.HandleFunc("/reports/idle", handlers.IdleCarsReport(s)).Methods("GET")
+	router.HandleFunc("/reports/revenue", handlers.RevenueReport(s)).Methods("GET")
+	router.HandleFunc("/events/cars", handlers.StreamCarEvents(s)).Methods("GET")
+	router.Handle("/graphql", graphqlapi.NewHandler(s)).Methods("POST")
+	router.HandleFunc("/webhooks", handlers.ListWebhooks(s)).Methods("GET")
+	router.HandleFunc("/webhooks", handlers.CreateWebhook(s)).Methods("POST")
+	router.HandleFunc("/webhooks/deliveries", handlers.ListWebhookDeliveries(s)).Methods("GET")
+	router.HandleFunc("/webhooks/deliveries/{id}/retry", handlers.RetryWebhookDelivery(s)).Methods("POST")
+	router.HandleFunc("/webhooks/{id}", handlers.DeleteWebhook(s)).Methods("DELETE")
+	router.HandleFunc("/blocklist", handlers.ListBlockedDrivers(s)).Methods("GET")
+	router.HandleFunc("/blocklist", handlers.BlockDriver(s)).Methods("POST")
+	router.HandleFunc("/blocklist/{id}", handlers.UnblockDriver(s)).Methods("DELETE")
+	router.HandleFunc("/notifications", handlers.ListNotifications(s)).Methods("GET")
+	router.HandleFunc("/notifications/{id}/retry", handlers.RetryNotification(s)).Methods("POST")
+	router.HandleFunc("/jobs", handlers.RequireAdmin(s, handlers.ListJobs(s))).Methods("GET")
+	router.HandleFunc("/jobs/runs", handlers.RequireAdmin(s, handlers.ListJobRuns(s))).Methods("GET")
+	router.HandleFunc("/jobs/{name}/runs", handlers.RequireAdmin(s, handlers.RunJob(s))).Methods("POST")
 }
@@ -0,0 +1,33 @@
+package search
+
+import (
+	"context"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// MemoryIndex scores every car of the tenant in the process. It works on any
+// database, such as SQLite in tests, at the cost of reading the whole fleet
+// for each search.
+type MemoryIndex struct {
+	db *gorm.DB
+}
+
+// NewMemoryIndex creates an index reading the cars from db.
+func NewMemoryIndex(db *gorm.DB) *MemoryIndex {
+	return &MemoryIndex{db: db}
+}
+
+// Search ranks every car against the query.
+func (m *MemoryIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	var docs []Document
+	err := m.db.WithContext(ctx).Model(&model.Car{}).
+		Select("id", "registration", "make", "car_model").
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return Rank(query, docs, limit), nil
+}
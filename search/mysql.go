@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// FullTextIndex is the MySQL full-text index of the make and model of cars,
+// created by service.MigrateDB.
+const FullTextIndex = "idx_cars_search"
+
+// minTokenSize is the shortest word indexed by InnoDB full-text indexes
+// (innodb_ft_min_token_size).
+const minTokenSize = 3
+
+// MySQLIndex selects candidate cars with the full-text index of their make
+// and model and a LIKE on their canonical registration, then ranks them in
+// the process.
+type MySQLIndex struct {
+	db *gorm.DB
+}
+
+// NewMySQLIndex creates an index searching the cars of db.
+func NewMySQLIndex(db *gorm.DB) *MySQLIndex {
+	return &MySQLIndex{db: db}
+}
+
+// Search ranks the cars sharing a word prefix or a part of the registration
+// with the query.
+func (m *MySQLIndex) Search(ctx context.Context, query string, limit int) ([]Hit, error) {
+	q := parseQuery(query)
+
+	var conditions []string
+	var args []interface{}
+
+	// Misspelled words usually start right: match the indexed words sharing
+	// the first letters of each query word.
+	var terms []string
+	for _, word := range q.words {
+		if len([]rune(word)) >= minTokenSize {
+			terms = append(terms, string([]rune(word)[:minTokenSize])+"*")
+		}
+	}
+	if len(terms) > 0 {
+		conditions = append(conditions, "MATCH(make, car_model) AGAINST (? IN BOOLEAN MODE)")
+		args = append(args, strings.Join(terms, " "))
+	}
+
+	// Registrations containing the pattern, or starting like it for fuzzy
+	// matches.
+	if plate := []rune(q.plate); len(plate) > 0 {
+		pattern := strings.ReplaceAll(q.plate, string(Wildcard), "_")
+		conditions = append(conditions, "canonical_registration LIKE ?")
+		args = append(args, "%"+pattern+"%")
+		if len(plate) >= 2 && plate[0] != Wildcard && plate[1] != Wildcard {
+			conditions = append(conditions, "canonical_registration LIKE ?")
+			args = append(args, string(plate[:2])+"%")
+		}
+	}
+
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	var docs []Document
+	err := m.db.WithContext(ctx).Model(&model.Car{}).
+		Select("id", "registration", "make", "car_model").
+		Where(strings.Join(conditions, " OR "), args...).
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return Rank(query, docs, limit), nil
+}
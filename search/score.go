@@ -0,0 +1,198 @@
+package search
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// Wildcard stands for a character of the registration the user does not
+// remember, as in "AB 12?".
+const Wildcard = '?'
+
+// minScore is the score below which a car is not a match.
+const minScore = 0.5
+
+// Scores of the ways a registration can match, best first. Fuzzy matches
+// score at most fuzzyPlate times their similarity.
+const (
+	exactPlate    = 1.0
+	wildcardPlate = 0.95
+	prefixPlate   = 0.9
+	containsPlate = 0.8
+	fuzzyPlate    = 0.7
+)
+
+// Scores of a query word against a word of the make or model.
+const (
+	exactWord  = 1.0
+	prefixWord = 0.9
+	fuzzyWord  = 0.8
+)
+
+// minSimilarity is the similarity below which two words or registrations are
+// considered unrelated.
+const minSimilarity = 0.6
+
+// query is a parsed search text.
+type query struct {
+	// plate is the text as a registration pattern: a canonical registration
+	// with wildcards.
+	plate string
+	// words are the lower-case words of the text.
+	words []string
+}
+
+func parseQuery(text string) query {
+	return query{
+		plate: normalizePlate(text, true),
+		words: splitWords(text),
+	}
+}
+
+// normalizePlate returns the canonical form of a registration, see
+// model.CanonicalRegistration. Wildcards are kept when keepWildcards is true.
+func normalizePlate(registration string, keepWildcards bool) string {
+	if !keepWildcards {
+		return model.CanonicalRegistration(registration)
+	}
+	parts := strings.Split(registration, string(Wildcard))
+	for i, part := range parts {
+		parts[i] = model.CanonicalRegistration(part)
+	}
+	return strings.Join(parts, string(Wildcard))
+}
+
+// splitWords returns the lower-case words of text.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// score returns how well the document matches the query, and the field that
+// matched best.
+func (q query) score(doc Document) (float64, Field) {
+	plate := plateScore(q.plate, normalizePlate(doc.Registration, false))
+	text := textScore(q.words, splitWords(doc.Make+" "+doc.CarModel))
+	if text > plate {
+		return text, FieldModel
+	}
+	return plate, FieldRegistration
+}
+
+// plateScore compares a registration pattern with a normalized registration.
+func plateScore(pattern, plate string) float64 {
+	p, r := []rune(pattern), []rune(plate)
+	if len(p) == 0 || len(r) == 0 {
+		return 0
+	}
+
+	if len(p) <= len(r) {
+		for start := 0; start+len(p) <= len(r); start++ {
+			if !matchAt(p, r[start:]) {
+				continue
+			}
+			switch {
+			case len(p) == len(r) && !strings.ContainsRune(pattern, Wildcard):
+				return exactPlate
+			case len(p) == len(r):
+				return wildcardPlate
+			case start == 0:
+				return prefixPlate
+			default:
+				return containsPlate
+			}
+		}
+	}
+
+	// Short patterns are too ambiguous for a fuzzy match.
+	if len(p) < 4 {
+		return 0
+	}
+	return fuzzy(p, r) * fuzzyPlate
+}
+
+// matchAt reports whether the pattern matches the start of plate.
+func matchAt(pattern, plate []rune) bool {
+	for i, c := range pattern {
+		if c != Wildcard && c != plate[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// textScore is the average, over the query words, of their best match among
+// the words of the make and model.
+func textScore(words, fields []string) float64 {
+	if len(words) == 0 || len(fields) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, word := range words {
+		best := 0.0
+		for _, field := range fields {
+			best = math.Max(best, wordScore(word, field))
+		}
+		total += best
+	}
+	return total / float64(len(words))
+}
+
+// wordScore compares a query word with a word of the make or model.
+func wordScore(word, field string) float64 {
+	switch {
+	case word == field:
+		return exactWord
+	case len(word) >= 2 && strings.HasPrefix(field, word):
+		return prefixWord
+	case len(word) >= 3:
+		return fuzzy([]rune(word), []rune(field)) * fuzzyWord
+	}
+	return 0
+}
+
+// fuzzy returns the similarity of a and b, from the edit distance relative to
+// the longest of them, or 0 below minSimilarity.
+func fuzzy(a, b []rune) float64 {
+	longest := math.Max(float64(len(a)), float64(len(b)))
+	similarity := 1 - float64(distance(a, b))/longest
+	if similarity < minSimilarity {
+		return 0
+	}
+	return similarity
+}
+
+// distance is the optimal string alignment distance between a and b: the
+// number of insertions, deletions, substitutions and transpositions of
+// adjacent characters turning one into the other. A wildcard in a is equal
+// to any character.
+func distance(a, b []rune) int {
+	// rows[i][j] is the distance between a[:i] and b[:j].
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] || a[i-1] == Wildcard {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
@@ -0,0 +1,86 @@
+// Package search finds cars from a half-remembered registration or model
+// name. Indexes only select candidate cars; every index ranks them with the
+// same scoring, so results do not depend on the database in use.
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Document is the searchable text of a car.
+type Document struct {
+	ID           uint
+	Registration string
+	Make         string
+	CarModel     string
+}
+
+// Field names the part of a car a hit matched.
+type Field string
+
+const (
+	FieldRegistration Field = "registration"
+	FieldModel        Field = "model"
+)
+
+// Hit is a car matching a query, with a score between 0 and 1.
+type Hit struct {
+	ID    uint
+	Score float64
+	Field Field
+}
+
+// Index searches the cars. MySQLIndex uses a full-text index of the cars
+// table; MemoryIndex scores every car in the process, for databases without
+// one.
+type Index interface {
+	Search(ctx context.Context, query string, limit int) ([]Hit, error)
+}
+
+// NewIndex returns the index suited to the database of db.
+func NewIndex(db *gorm.DB) Index {
+	if db.Dialector.Name() == "mysql" {
+		return NewMySQLIndex(db)
+	}
+	return NewMemoryIndex(db)
+}
+
+// Rank scores the documents against the query and returns the best limit
+// hits, highest score first. Documents scoring below minScore are dropped.
+func Rank(text string, docs []Document, limit int) []Hit {
+	q := parseQuery(text)
+
+	type ranked struct {
+		Hit
+		registration string
+	}
+	var hits []ranked
+	for _, doc := range docs {
+		score, field := q.score(doc)
+		if score < minScore {
+			continue
+		}
+		score = math.Round(score*1000) / 1000
+		hits = append(hits, ranked{Hit{ID: doc.ID, Score: score, Field: field}, doc.Registration})
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].registration < hits[j].registration
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	result := make([]Hit, len(hits))
+	for i, hit := range hits {
+		result[i] = hit.Hit
+	}
+	return result
+}
@@ -1,16 +1,94 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/events"
+	"github.com/abdeel07/backend-go-cars/idempotency"
+	"github.com/abdeel07/backend-go-cars/jobs"
+	"github.com/abdeel07/backend-go-cars/metrics"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/ratelimit"
 	"github.com/abdeel07/backend-go-cars/service"
+	"github.com/abdeel07/backend-go-cars/tenant"
 	"gorm.io/gorm"
 )
 
 type Server struct {
 	ParkingLotService *service.ParkingLotService
+	Metrics           *metrics.Metrics
+	Logger            *slog.Logger
+	Events            *events.Broker
+	Idempotency       *idempotency.Store
+	RateLimit         *ratelimit.Limiter
+	Tenants           *tenant.Resolver
+	Jobs              *jobs.Scheduler
+	Notifications     *notify.Dispatcher
+	// AdminToken guards the routes acting on the whole deployment, such as
+	// the background jobs and the metrics; they are refused when it is empty.
+	AdminToken string
+	// AllowPrivateWebhooks accepts webhook URLs on loopback, link-local and
+	// private addresses, for subscribers on the network of the deployment.
+	AllowPrivateWebhooks bool
+
+	shuttingDown atomic.Bool
 }
 
+// Background jobs run by every server.
+const (
+	// OverdueRentalsJob flags the rentals past their planned end.
+	OverdueRentalsJob = "overdue_rentals"
+	// PickupRemindersJob reminds customers of their upcoming reservations.
+	PickupRemindersJob = "pickup_reminders"
+	// DepositCapturesJob retries the deposit captures that failed.
+	DepositCapturesJob = "deposit_captures"
+)
+
 func NewServer(db *gorm.DB) *Server {
-	return &Server{
+	s := &Server{
 		ParkingLotService: service.NewParkingLotService(db),
+		Metrics:           metrics.New(db),
+		Logger:            slog.Default(),
+		Events:            events.NewBroker(db, slog.Default(), 1000),
+		Idempotency:       idempotency.NewStore(db, slog.Default()),
+		RateLimit:         ratelimit.New(ratelimit.NewMemoryStore(), slog.Default()),
+		Tenants:           tenant.NewResolver(),
+		Jobs:              jobs.NewScheduler(db, slog.Default()),
+		Notifications:     notify.NewDispatcher(db, slog.Default()),
 	}
+
+	s.Jobs.Register(jobs.Job{Name: OverdueRentalsJob, Interval: 5 * time.Minute, Run: s.flagOverdueRentals})
+	s.Jobs.Register(jobs.Job{Name: PickupRemindersJob, Interval: 15 * time.Minute, Run: s.remindPickups})
+	s.Jobs.Register(jobs.Job{Name: DepositCapturesJob, Interval: time.Minute, Run: s.captureDeposits})
+	return s
+}
+
+func (s *Server) flagOverdueRentals(ctx context.Context) (string, error) {
+	result, err := s.ParkingLotService.FlagOverdueRentals(ctx)
+	return fmt.Sprintf("%d rentals flagged overdue, %d late fees accrued", result.Flagged, result.Accrued), err
+}
+
+func (s *Server) remindPickups(ctx context.Context) (string, error) {
+	reminded, err := s.ParkingLotService.RemindPickups(ctx)
+	return fmt.Sprintf("%d customers reminded", reminded), err
+}
+
+func (s *Server) captureDeposits(ctx context.Context) (string, error) {
+	captured, err := s.ParkingLotService.CaptureDeposits(ctx)
+	return fmt.Sprintf("%d deposits captured", captured), err
+}
+
+// BeginShutdown marks the server as draining so readiness checks fail and
+// load balancers stop sending new requests.
+func (s *Server) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether BeginShutdown has been called.
+func (s *Server) ShuttingDown() bool {
+	return s.shuttingDown.Load()
 }
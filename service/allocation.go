@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"gorm.io/gorm"
+)
+
+// AllocationStrategy chooses which available car is handed out when a
+// customer rents by category rather than by registration.
+type AllocationStrategy string
+
+const (
+	// LowestMileage picks the car with the fewest kilometers on the odometer.
+	LowestMileage AllocationStrategy = "lowest_mileage"
+	// RoundRobin picks the car that has waited longest since its last rental.
+	RoundRobin AllocationStrategy = "round_robin"
+	// ServiceDue picks the car with the fewest kilometers left before its next
+	// service, so cars are used up before they go to the workshop.
+	ServiceDue AllocationStrategy = "service_due"
+)
+
+var (
+	ErrUnknownStrategy = errors.New("unknown allocation strategy")
+	ErrNoCarAvailable  = errors.New("no car available in this category")
+)
+
+// ParseAllocationStrategy validates a strategy name, defaulting to LowestMileage.
+func ParseAllocationStrategy(name string) (AllocationStrategy, error) {
+	switch strategy := AllocationStrategy(name); strategy {
+	case "":
+		return LowestMileage, nil
+	case LowestMileage, RoundRobin, ServiceDue:
+		return strategy, nil
+	default:
+		return "", ErrUnknownStrategy
+	}
+}
+
+// less reports whether car a should be preferred over car b.
+func (strategy AllocationStrategy) less(a, b model.Car) bool {
+	switch strategy {
+	case RoundRobin:
+		if a.LastRentedAt == nil || b.LastRentedAt == nil {
+			if (a.LastRentedAt == nil) != (b.LastRentedAt == nil) {
+				return a.LastRentedAt == nil
+			}
+		} else if !a.LastRentedAt.Equal(*b.LastRentedAt) {
+			return a.LastRentedAt.Before(*b.LastRentedAt)
+		}
+	case ServiceDue:
+		aDue, bDue := kilometersToService(a), kilometersToService(b)
+		if aDue != bDue {
+			return aDue < bDue
+		}
+	default:
+		if a.Mileage != b.Mileage {
+			return a.Mileage < b.Mileage
+		}
+	}
+
+	return a.ID < b.ID
+}
+
+// kilometersToService returns how far a car can still be driven before its
+// next service. Cars without a scheduled service are sorted last.
+func kilometersToService(car model.Car) float64 {
+	if car.NextServiceMileage <= 0 {
+		return math.MaxFloat64
+	}
+	return car.NextServiceMileage - car.Mileage
+}
+
+// RentByCategory picks an available car matching the category pattern using
+// the given strategy, marks it as rented and returns it with its rental.
+func (s *ParkingLotService) RentByCategory(ctx context.Context, pattern string, strategy AllocationStrategy, opts RentOptions) (model.Car, model.Rental, error) {
+	// The deposit is held before the cars are locked, and released if none
+	// of them is rented after all.
+	deposit, err := s.authorizeDeposit(ctx, opts)
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+
+	car, rental, err := s.rentByCategory(ctx, pattern, strategy, opts, deposit)
+	if err != nil {
+		err = s.releaseDeposit(ctx, deposit, err)
+	}
+	return car, rental, err
+}
+
+// rentByCategory is RentByCategory once the deposit, if any, is held under
+// the deposit reference.
+func (s *ParkingLotService) rentByCategory(ctx context.Context, pattern string, strategy AllocationStrategy, opts RentOptions, deposit string) (model.Car, model.Rental, error) {
+	s.CarsMutex.Lock()
+	defer s.CarsMutex.Unlock()
+
+	drivers, err := opts.drivers(s.DB.WithContext(ctx))
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+
+	var cars []model.Car
+	err = s.DB.WithContext(ctx).
+		Where("status = ? AND category LIKE ?", model.StatusAvailable, model.CategoryLikePattern(pattern)).
+		Find(&cars).Error
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+
+	sort.Slice(cars, func(i, j int) bool {
+		return strategy.less(cars[i], cars[j])
+	})
+
+	// The drivers may be too young for some of the categories matching the
+	// pattern: only hand out cars they are eligible for, and report why the
+	// preferred car was refused if there are none.
+	now := time.Now()
+	var eligible []model.Car
+	var refused error
+	for _, car := range cars {
+		err := s.Eligibility.Check(drivers, car.Category, now)
+		if err == nil {
+			eligible = append(eligible, car)
+		} else if refused == nil {
+			refused = err
+		}
+	}
+	if len(eligible) == 0 && refused != nil {
+		return model.Car{}, model.Rental{}, refused
+	}
+	surcharge := s.Eligibility.Surcharge(drivers, now)
+
+	// Another replica may rent the preferred car first, in which case the
+	// guarded transition fails and the next candidate is tried, as it is when
+	// the car is reserved during the rental.
+	for _, car := range eligible {
+		var rental model.Rental
+		now := time.Now()
+		car.LastRentedAt = &now
+		err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := transition(tx, &car, model.StatusRented, "rented by category "+pattern, "last_rented_at"); err != nil {
+				return err
+			}
+
+			var err error
+			if rental, err = startRental(tx, &car, opts, surcharge); err != nil {
+				return err
+			}
+
+			if err := s.recordDeposit(tx, &rental, deposit); err != nil {
+				return err
+			}
+
+			err = s.notifyCustomer(tx, model.NotifyBookingConfirmation, rental.CustomerID, notify.Data{Car: car, Rental: &rental})
+			if err != nil {
+				return err
+			}
+
+			return emit(tx, model.EventCarRented, FleetEvent{Car: car, Rental: &rental})
+		})
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrReservationConflict) {
+			continue
+		}
+		if err != nil {
+			return model.Car{}, model.Rental{}, err
+		}
+		return car, rental, nil
+	}
+
+	return model.Car{}, model.Rental{}, ErrNoCarAvailable
+}
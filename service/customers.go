@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/eligibility"
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+var ErrCustomerNotFound = errors.New("customer not found")
+
+// CustomerFilter holds the optional criteria used to list customers.
+type CustomerFilter struct {
+	// Search matches a part of the name or email.
+	Search  string
+	AfterID uint
+	Limit   int
+}
+
+// RentOptions holds the optional details of a rental.
+type RentOptions struct {
+	CustomerID *uint
+	// AdditionalDriverIDs are the customers allowed to drive the car besides
+	// the one renting it.
+	AdditionalDriverIDs []uint
+	// PaymentMethod is the provider token of the card holding the deposit.
+	PaymentMethod string
+	// PlannedEndAt is when the car is expected back, if known.
+	PlannedEndAt *time.Time
+}
+
+// additionalDrivers returns the ids of the additional drivers, without
+// duplicates or the customer.
+func (opts RentOptions) additionalDrivers() []uint {
+	var ids []uint
+	for _, id := range opts.AdditionalDriverIDs {
+		if (opts.CustomerID == nil || id != *opts.CustomerID) && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// drivers loads the customer and additional drivers of the options, the
+// customer first, with the reason they are blocked if they are on the
+// blocklist.
+func (opts RentOptions) drivers(tx *gorm.DB) ([]eligibility.Driver, error) {
+	if opts.CustomerID == nil {
+		if len(opts.AdditionalDriverIDs) > 0 {
+			return nil, &eligibility.Error{Failures: []eligibility.Failure{
+				{Rule: eligibility.RuleDriverRequired, Message: "additional drivers need a customer on the agreement"},
+			}}
+		}
+		return nil, nil
+	}
+
+	ids := append([]uint{*opts.CustomerID}, opts.additionalDrivers()...)
+	customers := make([]model.Customer, len(ids))
+	for i, id := range ids {
+		var err error
+		if customers[i], err = findCustomer(tx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	blocked, err := blocklisted(tx, customers)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers := make([]eligibility.Driver, len(customers))
+	for i, customer := range customers {
+		drivers[i] = eligibility.Driver{
+			CustomerID:      customer.ID,
+			DateOfBirth:     customer.DateOfBirth,
+			LicenceIssuedAt: customer.LicenceIssuedAt,
+			Blocked:         blocked[customer.ID],
+		}
+	}
+	return drivers, nil
+}
+
+// findCustomer loads a customer by id, mapping a missing row to ErrCustomerNotFound.
+func findCustomer(tx *gorm.DB, id uint) (model.Customer, error) {
+	var customer model.Customer
+	err := tx.First(&customer, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return customer, ErrCustomerNotFound
+	}
+	return customer, err
+}
+
+// CreateCustomer registers a customer.
+func (s *ParkingLotService) CreateCustomer(ctx context.Context, customer *model.Customer) error {
+	customer.LicenceNumber = model.NormalizeLicenceNumber(customer.LicenceNumber)
+	return s.DB.WithContext(ctx).Create(customer).Error
+}
+
+// GetCustomer returns the customer with the given id.
+func (s *ParkingLotService) GetCustomer(ctx context.Context, id uint) (model.Customer, error) {
+	return findCustomer(s.DB.WithContext(ctx), id)
+}
+
+// ListCustomers returns the customers matching the filter, ordered by id.
+func (s *ParkingLotService) ListCustomers(ctx context.Context, filter CustomerFilter) ([]model.Customer, error) {
+	query := s.DB.WithContext(ctx).Order("id")
+
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("name LIKE ? OR email LIKE ?", pattern, pattern)
+	}
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var customers []model.Customer
+	err := query.Find(&customers).Error
+	return customers, err
+}
+
+// CustomersByID loads the customers with the given ids in one query.
+func (s *ParkingLotService) CustomersByID(ctx context.Context, ids []uint) (map[uint]model.Customer, error) {
+	customers := make(map[uint]model.Customer, len(ids))
+	if len(ids) == 0 {
+		return customers, nil
+	}
+
+	var rows []model.Customer
+	if err := s.DB.WithContext(ctx).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, customer := range rows {
+		customers[customer.ID] = customer
+	}
+	return customers, nil
+}
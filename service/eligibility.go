@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+var ErrBlockedDriverNotFound = errors.New("blocklist entry not found")
+
+// blocklisted returns, for the customers on the blocklist, the reason they
+// were blocked. Customers match an entry by licence number or email.
+func blocklisted(tx *gorm.DB, customers []model.Customer) (map[uint]string, error) {
+	var licences, emails []string
+	for _, customer := range customers {
+		if customer.LicenceNumber != "" {
+			licences = append(licences, model.NormalizeLicenceNumber(customer.LicenceNumber))
+		}
+		if customer.Email != "" {
+			emails = append(emails, strings.ToLower(customer.Email))
+		}
+	}
+
+	blocked := map[uint]string{}
+	if len(licences) == 0 && len(emails) == 0 {
+		return blocked, nil
+	}
+
+	var entries []model.BlockedDriver
+	if err := tx.Where("licence_number IN ? OR email IN ?", licences, emails).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		for _, customer := range customers {
+			if (entry.LicenceNumber != "" && entry.LicenceNumber == model.NormalizeLicenceNumber(customer.LicenceNumber)) ||
+				(entry.Email != "" && strings.EqualFold(entry.Email, customer.Email)) {
+				reason := entry.Reason
+				if reason == "" {
+					reason = "no reason given"
+				}
+				blocked[customer.ID] = reason
+			}
+		}
+	}
+	return blocked, nil
+}
+
+// BlockDriver adds a licence number or email to the blocklist.
+func (s *ParkingLotService) BlockDriver(ctx context.Context, entry *model.BlockedDriver) error {
+	entry.LicenceNumber = model.NormalizeLicenceNumber(entry.LicenceNumber)
+	entry.Email = strings.ToLower(strings.TrimSpace(entry.Email))
+	return s.DB.WithContext(ctx).Create(entry).Error
+}
+
+// ListBlockedDrivers returns the blocklist, oldest entry first.
+func (s *ParkingLotService) ListBlockedDrivers(ctx context.Context) ([]model.BlockedDriver, error) {
+	var entries []model.BlockedDriver
+	err := s.DB.WithContext(ctx).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// UnblockDriver removes an entry from the blocklist.
+func (s *ParkingLotService) UnblockDriver(ctx context.Context, id uint) error {
+	result := s.DB.WithContext(ctx).Delete(&model.BlockedDriver{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBlockedDriverNotFound
+	}
+	return nil
+}
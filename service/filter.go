@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+
+	"github.com/abdeel07/backend-go-cars/model"
+)
+
+// CarFilter holds the optional criteria used to search the fleet. Zero values
+// are ignored.
+type CarFilter struct {
+	Make         string
+	CarModel     string
+	Colour       string
+	VIN          string
+	Category     string
+	Branch       string
+	Transmission model.Transmission
+	FuelType     model.FuelType
+	MinYear      int
+	MaxYear      int
+	MinSeats     int
+	Status       model.CarStatus
+	Available    *bool
+	// AfterID and Limit page through the cars in id order.
+	AfterID uint
+	Limit   int
+}
+
+// ListCars returns the cars matching every criteria set in the filter.
+func (s *ParkingLotService) ListCars(ctx context.Context, filter CarFilter) ([]model.Car, error) {
+	query := s.DB.WithContext(ctx).Model(&model.Car{}).Order("id")
+
+	if filter.Make != "" {
+		query = query.Where("make = ?", filter.Make)
+	}
+	if filter.CarModel != "" {
+		query = query.Where("car_model LIKE ?", "%"+filter.CarModel+"%")
+	}
+	if filter.Colour != "" {
+		query = query.Where("colour = ?", filter.Colour)
+	}
+	if filter.VIN != "" {
+		query = query.Where("vin = ?", filter.VIN)
+	}
+	if filter.Category != "" {
+		query = query.Where("category LIKE ?", model.CategoryLikePattern(filter.Category))
+	}
+	if filter.Branch != "" {
+		query = query.Where("branch = ?", filter.Branch)
+	}
+	if filter.Transmission != "" {
+		query = query.Where("transmission = ?", filter.Transmission)
+	}
+	if filter.FuelType != "" {
+		query = query.Where("fuel_type = ?", filter.FuelType)
+	}
+	if filter.MinYear > 0 {
+		query = query.Where("year >= ?", filter.MinYear)
+	}
+	if filter.MaxYear > 0 {
+		query = query.Where("year <= ?", filter.MaxYear)
+	}
+	if filter.MinSeats > 0 {
+		query = query.Where("seats >= ?", filter.MinSeats)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Available != nil {
+		if *filter.Available {
+			query = query.Where("status = ?", model.StatusAvailable)
+		} else {
+			query = query.Where("status <> ?", model.StatusAvailable)
+		}
+	}
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var cars []model.Car
+	if err := query.Find(&cars).Error; err != nil {
+		return nil, err
+	}
+
+	return cars, nil
+}
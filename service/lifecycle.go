@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCarNotFound       = errors.New("car not found")
+	ErrCarExists         = errors.New("car already exists")
+	ErrVINExists         = errors.New("a car with this VIN already exists")
+	ErrInvalidTransition = errors.New("invalid car status transition")
+)
+
+// TransitionError describes a status change refused by the transition table,
+// or lost to a concurrent change of the same car.
+type TransitionError struct {
+	From model.CarStatus
+	To   model.CarStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("car cannot go from %s to %s", e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) match every TransitionError.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// transition moves a car to a new status inside tx. It is the only place
+// where the status column is written: the change is checked against the
+// transition table, applied only if nobody changed the car meanwhile, and
+// recorded with its timestamp. Extra columns already set on car are saved in
+// the same update.
+func transition(tx *gorm.DB, car *model.Car, to model.CarStatus, reason string, columns ...string) error {
+	from := car.Status
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+
+	now := time.Now()
+	version := car.Version
+	car.Status = to
+	car.StatusChangedAt = &now
+	car.Available = to == model.StatusAvailable
+	car.Version++
+
+	columns = append(columns, "status", "status_changed_at", "version")
+	result := tx.Model(car).Where("status = ? AND version = ?", from, version).Select(columns).Updates(car)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		car.Status = from
+		car.Version = version
+		return &TransitionError{From: from, To: to}
+	}
+
+	return tx.Create(&model.CarStatusTransition{
+		CarID:     car.ID,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		CreatedAt: now,
+	}).Error
+}
+
+// findCar loads a car by registration, in any of its spellings, returning
+// ErrCarNotFound if missing, or ErrVersionMismatch if the context expects
+// another version of the car.
+func findCar(tx *gorm.DB, registration string) (model.Car, error) {
+	var car model.Car
+	err := tx.First(&car, "canonical_registration = ?", model.CanonicalRegistration(registration)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Car{}, ErrCarNotFound
+	}
+	if err != nil {
+		return car, err
+	}
+	return car, checkVersion(tx.Statement.Context, car)
+}
+
+// GetCar returns the car with the given registration.
+func (s *ParkingLotService) GetCar(ctx context.Context, registration string) (model.Car, error) {
+	return findCar(s.DB.WithContext(ctx), registration)
+}
+
+// AddCar registers a new car in the fleet as available.
+func (s *ParkingLotService) AddCar(ctx context.Context, car *model.Car) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		canonical := model.CanonicalRegistration(car.Registration)
+		if err := tx.Model(&model.Car{}).Where("canonical_registration = ?", canonical).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCarExists
+		}
+		if car.VIN != "" {
+			if err := tx.Model(&model.Car{}).Where("vin = ?", car.VIN).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVINExists
+			}
+		}
+
+		now := time.Now()
+		car.Status = model.StatusAvailable
+		car.StatusChangedAt = &now
+		car.Available = true
+		car.Version = 1
+		if err := tx.Create(car).Error; err != nil {
+			// A concurrent request added the same registration first.
+			if duplicateKey(err) {
+				return ErrCarExists
+			}
+			return err
+		}
+
+		err := tx.Create(&model.CarStatusTransition{
+			CarID:     car.ID,
+			To:        model.StatusAvailable,
+			Reason:    "added to fleet",
+			CreatedAt: now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := recordOdometer(tx, car.ID, nil, car.Mileage, model.OdometerAdded); err != nil {
+			return err
+		}
+
+		return emit(tx, model.EventCarAdded, FleetEvent{Car: *car})
+	})
+}
+
+// duplicateKey reports whether err is the MySQL error of an insert breaking
+// a unique index.
+func duplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// RentCar hands out the car with the given registration and opens its rental.
+func (s *ParkingLotService) RentCar(ctx context.Context, registration string, opts RentOptions) (model.Car, model.Rental, error) {
+	// A declined deposit refuses the rental before anything is locked.
+	deposit, err := s.authorizeDeposit(ctx, opts)
+	if err != nil {
+		return model.Car{}, model.Rental{}, err
+	}
+
+	var car model.Car
+	var rental model.Rental
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if car, err = findCar(tx, registration); err != nil {
+			return err
+		}
+		drivers, err := opts.drivers(tx)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := s.Eligibility.Check(drivers, car.Category, now); err != nil {
+			return err
+		}
+
+		car.LastRentedAt = &now
+		if err := transition(tx, &car, model.StatusRented, "rented", "last_rented_at"); err != nil {
+			return err
+		}
+
+		if rental, err = startRental(tx, &car, opts, s.Eligibility.Surcharge(drivers, now)); err != nil {
+			return err
+		}
+
+		if err := s.recordDeposit(tx, &rental, deposit); err != nil {
+			return err
+		}
+
+		err = s.notifyCustomer(tx, model.NotifyBookingConfirmation, rental.CustomerID, notify.Data{Car: car, Rental: &rental})
+		if err != nil {
+			return err
+		}
+
+		return emit(tx, model.EventCarRented, FleetEvent{Car: car, Rental: &rental})
+	})
+	if err != nil {
+		err = s.releaseDeposit(ctx, deposit, err)
+	}
+
+	return car, rental, err
+}
+
+// ReturnCar takes back a rented car given the absolute odometer reading at
+// return, which becomes the car mileage.
+func (s *ParkingLotService) ReturnCar(ctx context.Context, registration string, odometer float64) (model.Car, model.Rental, error) {
+	var car model.Car
+	var rental model.Rental
+	var capture *model.Payment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if car, err = findCar(tx, registration); err != nil {
+			return err
+		}
+		if car.Status != model.StatusRented {
+			return &TransitionError{From: car.Status, To: model.StatusAvailable}
+		}
+
+		if rental, err = openRental(tx, &car); err != nil {
+			return err
+		}
+		if err := finishRental(tx, &rental, odometer, s.Returns); err != nil {
+			return err
+		}
+
+		car.Mileage = odometer
+		if err := transition(tx, &car, model.StatusAvailable, "returned", "mileage"); err != nil {
+			return err
+		}
+
+		// The deposit is settled once the checks that can refuse the
+		// return have passed.
+		if capture, err = s.settleDeposit(tx, &rental); err != nil {
+			return err
+		}
+
+		err = s.notifyCustomer(tx, model.NotifyInvoice, rental.CustomerID, notify.Data{Car: car, Rental: &rental})
+		if err != nil {
+			return err
+		}
+
+		return emit(tx, model.EventCarReturned, FleetEvent{Car: car, Rental: &rental})
+	})
+
+	// The car is back: a capture that cannot be made now, or cannot be
+	// recorded, is left pending for CaptureDeposits to retry.
+	if err == nil && capture != nil {
+		s.captureDeposit(context.WithoutCancel(ctx), capture)
+	}
+
+	return car, rental, err
+}
+
+// SetStatus moves a car to a status that is not driven by the rental flow,
+// such as maintenance, in transit or lost.
+func (s *ParkingLotService) SetStatus(ctx context.Context, registration string, status model.CarStatus, reason string) (model.Car, error) {
+	var car model.Car
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if car, err = findCar(tx, registration); err != nil {
+			return err
+		}
+
+		// A rented car only becomes available again through ReturnCar, which
+		// also records its mileage.
+		if car.Status == model.StatusRented && status == model.StatusAvailable {
+			return &TransitionError{From: car.Status, To: status}
+		}
+
+		if err := transition(tx, &car, status, reason); err != nil {
+			return err
+		}
+
+		return emit(tx, model.EventCarStatusChanged, FleetEvent{Car: car})
+	})
+
+	return car, err
+}
+
+// DeleteCar retires a car and removes it from the fleet.
+func (s *ParkingLotService) DeleteCar(ctx context.Context, registration string) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		car, err := findCar(tx, registration)
+		if err != nil {
+			return err
+		}
+
+		if err := transition(tx, &car, model.StatusRetired, "deleted"); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&car).Error; err != nil {
+			return err
+		}
+
+		return emit(tx, model.EventCarDeleted, FleetEvent{Car: car})
+	})
+}
+
+// StatusHistory returns the status transitions of a car, oldest first.
+func (s *ParkingLotService) StatusHistory(ctx context.Context, registration string) ([]model.CarStatusTransition, error) {
+	car, err := findCar(s.DB.WithContext(ctx), registration)
+	if err != nil {
+		return nil, err
+	}
+
+	var transitions []model.CarStatusTransition
+	err = s.DB.WithContext(ctx).Where("car_id = ?", car.ID).Order("created_at, id").Find(&transitions).Error
+	return transitions, err
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// MaxPlausibleSpeed is the average speed, in km/h, above which the distance
+// driven during a rental is flagged for manager review.
+var MaxPlausibleSpeed = 130.0
+
+var (
+	ErrOdometerRollback = errors.New("odometer reading is lower than at checkout")
+	ErrRentalNotFound   = errors.New("rental not found")
+)
+
+// startRental opens a rental for a car that has just been rented and records
+// its checkout odometer reading. surcharge is added to the daily rate of the
+// car for young drivers. The car must not be reserved before the planned
+// end of the rental, or right now if it has none.
+func startRental(tx *gorm.DB, car *model.Car, opts RentOptions, surcharge float64) (model.Rental, error) {
+	now := time.Now()
+	end := now.Add(time.Second)
+	if opts.PlannedEndAt != nil {
+		if !opts.PlannedEndAt.After(now) {
+			return model.Rental{}, ErrInvalidPeriod
+		}
+		end = *opts.PlannedEndAt
+	}
+	if err := checkReservations(tx, car.ID, opts.CustomerID, now, end); err != nil {
+		return model.Rental{}, err
+	}
+
+	rental := model.Rental{
+		CarID:                car.ID,
+		CustomerID:           opts.CustomerID,
+		StartedAt:            now,
+		PlannedEndAt:         opts.PlannedEndAt,
+		StartOdometer:        car.Mileage,
+		DailyRate:            car.DailyRate,
+		YoungDriverSurcharge: surcharge,
+	}
+	if opts.PlannedEndAt != nil {
+		rental.PlannedAmount = rentalPrice(rental.DailyRate+surcharge, now, *opts.PlannedEndAt)
+	}
+	for _, id := range opts.additionalDrivers() {
+		rental.AdditionalDrivers = append(rental.AdditionalDrivers, model.RentalDriver{CustomerID: id})
+	}
+	if err := tx.Create(&rental).Error; err != nil {
+		return model.Rental{}, err
+	}
+
+	return rental, recordOdometer(tx, car.ID, &rental.ID, car.Mileage, model.OdometerCheckout)
+}
+
+// openRental returns the rental in progress for a car. Cars rented before
+// rentals were tracked have none, so one is reconstructed from the car.
+func openRental(tx *gorm.DB, car *model.Car) (model.Rental, error) {
+	var rental model.Rental
+	err := tx.Where("car_id = ? AND ended_at IS NULL", car.ID).Order("started_at DESC").First(&rental).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return rental, err
+	}
+
+	rental = model.Rental{CarID: car.ID, StartedAt: time.Now(), StartOdometer: car.Mileage, DailyRate: car.DailyRate}
+	if car.LastRentedAt != nil {
+		rental.StartedAt = *car.LastRentedAt
+	} else if car.StatusChangedAt != nil {
+		rental.StartedAt = *car.StatusChangedAt
+	}
+
+	return rental, tx.Create(&rental).Error
+}
+
+// finishRental closes a rental with the absolute odometer reading taken at
+// return, flagging distances that are implausible for the rental duration.
+// Rentals with a planned end are priced by the return policy, the difference
+// with their quote being their adjustment.
+func finishRental(tx *gorm.DB, rental *model.Rental, odometer float64, policy ReturnPolicy) error {
+	if odometer < rental.StartOdometer {
+		return ErrOdometerRollback
+	}
+
+	now := time.Now()
+	rental.EndedAt = &now
+	rental.EndOdometer = &odometer
+	rental.Distance = odometer - rental.StartOdometer
+	rental.Amount = policy.price(rental.DailyRate+rental.YoungDriverSurcharge, rental.StartedAt, rental.PlannedEndAt, now)
+	if rental.PlannedEndAt != nil {
+		rental.Adjustment = rental.Amount - rental.PlannedAmount
+		rental.LateFee = policy.lateFee(rental.DailyRate+rental.YoungDriverSurcharge, *rental.PlannedEndAt, now)
+	}
+
+	// Allow at least one hour of driving so short rentals are not all flagged.
+	hours := math.Max(now.Sub(rental.StartedAt).Hours(), 1)
+	if speed := rental.Distance / hours; speed > MaxPlausibleSpeed {
+		rental.NeedsReview = true
+		rental.ReviewReason = fmt.Sprintf("%.1f km in %.1f h is an average of %.0f km/h", rental.Distance, hours, speed)
+	}
+
+	if err := tx.Save(rental).Error; err != nil {
+		return err
+	}
+
+	return recordOdometer(tx, rental.CarID, &rental.ID, odometer, model.OdometerReturn)
+}
+
+func recordOdometer(tx *gorm.DB, carID uint, rentalID *uint, reading float64, source model.OdometerSource) error {
+	return tx.Create(&model.OdometerReading{
+		CarID:    carID,
+		RentalID: rentalID,
+		Reading:  reading,
+		Source:   source,
+	}).Error
+}
+
+// OdometerHistory returns every odometer reading recorded for a car, oldest first.
+func (s *ParkingLotService) OdometerHistory(ctx context.Context, registration string) ([]model.OdometerReading, error) {
+	car, err := findCar(s.DB.WithContext(ctx), registration)
+	if err != nil {
+		return nil, err
+	}
+
+	var readings []model.OdometerReading
+	err = s.DB.WithContext(ctx).Where("car_id = ?", car.ID).Order("created_at, id").Find(&readings).Error
+	return readings, err
+}
+
+// PendingReviews returns the rentals flagged for review that no manager has
+// looked at yet.
+func (s *ParkingLotService) PendingReviews(ctx context.Context) ([]model.Rental, error) {
+	var rentals []model.Rental
+	err := s.DB.WithContext(ctx).Preload("Car").
+		Where("needs_review = ? AND reviewed_at IS NULL", true).
+		Order("ended_at").Find(&rentals).Error
+	return rentals, err
+}
+
+// ReviewRental marks a flagged rental as reviewed by a manager.
+func (s *ParkingLotService) ReviewRental(ctx context.Context, id uint, note string) (model.Rental, error) {
+	var rental model.Rental
+	err := s.DB.WithContext(ctx).First(&rental, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rental, ErrRentalNotFound
+	}
+	if err != nil {
+		return rental, err
+	}
+
+	now := time.Now()
+	rental.ReviewedAt = &now
+	rental.ReviewNote = note
+	return rental, s.DB.WithContext(ctx).Save(&rental).Error
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"gorm.io/gorm"
+)
+
+// FleetEvent is the payload of the events published when a car changes.
+type FleetEvent struct {
+	Car    model.Car     `json:"car"`
+	Rental *model.Rental `json:"rental,omitempty"`
+}
+
+// emit writes an event to the outbox inside tx, so it is committed or rolled
+// back together with the change it describes.
+func emit(tx *gorm.DB, eventType string, event FleetEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&model.OutboxEvent{Type: eventType, Payload: string(payload)}).Error
+}
+
+// notifyCustomer queues a notification to the customer of a rental or a
+// reservation, if it names one, inside tx like emit.
+func (s *ParkingLotService) notifyCustomer(tx *gorm.DB, kind model.NotificationKind, customerID *uint, data notify.Data) error {
+	if customerID == nil {
+		return nil
+	}
+	return s.Notifications.Enqueue(tx, kind, *customerID, data)
+}
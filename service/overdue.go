@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"gorm.io/gorm"
+)
+
+// OverdueResult counts the rentals changed by FlagOverdueRentals.
+type OverdueResult struct {
+	// Flagged rentals became overdue during the run.
+	Flagged int
+	// Accrued rentals were already overdue and their late fee grew.
+	Accrued int
+}
+
+// FlagOverdueRentals marks the open rentals whose planned end and grace
+// period have passed as overdue, emitting a rental.overdue event and
+// notifying the customer the first time, and brings their late fee up to
+// date. It runs across tenants unless
+// ctx carries one.
+func (s *ParkingLotService) FlagOverdueRentals(ctx context.Context) (OverdueResult, error) {
+	var result OverdueResult
+	now := time.Now()
+
+	var rentals []model.Rental
+	err := s.DB.WithContext(ctx).
+		Where("ended_at IS NULL AND planned_end_at < ?", now.Add(-s.Returns.Grace)).
+		Order("id").Find(&rentals).Error
+	if err != nil {
+		return result, err
+	}
+
+	for _, rental := range rentals {
+		fee := s.Returns.lateFee(rental.DailyRate+rental.YoungDriverSurcharge, *rental.PlannedEndAt, now)
+		if rental.Overdue && fee == rental.LateFee {
+			continue
+		}
+
+		// The event is written to the outbox of the tenant of the rental.
+		flagged := !rental.Overdue
+		rentalCtx := tenant.WithID(ctx, rental.TenantID)
+		err := s.DB.WithContext(rentalCtx).Transaction(func(tx *gorm.DB) error {
+			rental.LateFee = fee
+			if flagged {
+				rental.Overdue = true
+				rental.OverdueAt = &now
+			}
+
+			// The rental may have been returned or extended meanwhile.
+			update := tx.Model(&rental).Where("ended_at IS NULL AND planned_end_at = ?", *rental.PlannedEndAt).
+				Select("overdue", "overdue_at", "late_fee").Updates(&rental)
+			if update.Error != nil {
+				return update.Error
+			}
+			if update.RowsAffected == 0 {
+				return nil
+			}
+			if !flagged {
+				result.Accrued++
+				return nil
+			}
+
+			var car model.Car
+			if err := tx.Unscoped().First(&car, rental.CarID).Error; err != nil {
+				return err
+			}
+			result.Flagged++
+			if rental.CustomerID != nil {
+				err := s.Notifications.Enqueue(tx, model.NotifyOverdueReturn, *rental.CustomerID, notify.Data{Car: car, Rental: &rental})
+				if err != nil {
+					return err
+				}
+			}
+			return emit(tx, model.EventRentalOverdue, FleetEvent{Car: car, Rental: &rental})
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
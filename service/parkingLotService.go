@@ -1,26 +1,45 @@
 package service
 
 import (
-	"fmt"
+	"context"
 	"sync"
+	"time"
 
+	"github.com/abdeel07/backend-go-cars/eligibility"
 	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/payment"
+	"github.com/abdeel07/backend-go-cars/search"
+	"github.com/abdeel07/backend-go-cars/tenant"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 type ParkingLotService struct {
-	DB        *gorm.DB
-	CarsMutex *sync.Mutex
+	DB          *gorm.DB
+	CarsMutex   *sync.Mutex
+	Search      search.Index
+	Eligibility eligibility.Rules
+	// Payments holds a deposit of Deposit on every rental; rentals move no
+	// money when it is nil.
+	Payments payment.Provider
+	Deposit  float64
+	// CaptureAttempts is how many times the capture of a returned rental
+	// is tried before its deposit is released and the amount claimed as a
+	// balance due.
+	CaptureAttempts int
+	Returns         ReturnPolicy
+	// Notifications queues the messages to customers about their rentals,
+	// and ReminderLead is how long before a reservation starts they are
+	// reminded of it.
+	Notifications *notify.Outbox
+	ReminderLead  time.Duration
 }
 
 func (s *ParkingLotService) IsExist(registration string) (bool, model.Car) {
 
-	var car model.Car
-	result := s.DB.First(&car, "registration = ?", registration)
-
-	if result.Error != nil {
-		fmt.Printf("Record not found for registration '%s'\n", registration)
+	car, err := findCar(s.DB, registration)
+	if err != nil {
 		return false, model.Car{}
 	}
 
@@ -29,21 +48,107 @@ func (s *ParkingLotService) IsExist(registration string) (bool, model.Car) {
 
 func NewParkingLotService(db *gorm.DB) *ParkingLotService {
 	return &ParkingLotService{
-		DB:        db,
-		CarsMutex: &sync.Mutex{},
+		DB:              db,
+		CarsMutex:       &sync.Mutex{},
+		Search:          search.NewIndex(db),
+		Eligibility:     eligibility.DefaultRules(),
+		CaptureAttempts: 5,
+		Returns:         DefaultReturnPolicy(),
+		Notifications:   notify.NewOutbox(db),
+		ReminderLead:    24 * time.Hour,
 	}
 }
 
-func MigrateDB(db *gorm.DB) {
-	db.AutoMigrate(&model.Car{})
+// models lists every table managed by MigrateDB.
+var models = []interface{}{
+	&model.Car{},
+	&model.CarStatusTransition{},
+	&model.Customer{},
+	&model.BlockedDriver{},
+	&model.Rental{},
+	&model.Reservation{},
+	&model.RentalDriver{},
+	&model.Payment{},
+	&model.OdometerReading{},
+	&model.OutboxEvent{},
+	&model.WebhookSubscription{},
+	&model.WebhookDelivery{},
+	&model.IdempotencyKey{},
+	&model.Notification{},
+	&model.JobLock{},
+	&model.JobRun{},
 }
 
-func InitializeDB(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+func MigrateDB(db *gorm.DB) error {
+	// The canonical registrations of the cars added before they were stored
+	// must be computed before their unique index is created.
+	if err := prepareCanonicalRegistrations(db); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(models...); err != nil {
+		return err
+	}
+
+	// Cars created before the status column existed only carry the old
+	// available flag; derive their status from it once.
+	if db.Migrator().HasColumn(&model.Car{}, "available") {
+		db.Exec("UPDATE cars SET status = CASE WHEN available THEN ? ELSE ? END WHERE status IS NULL OR status = ''",
+			model.StatusAvailable, model.StatusRented)
+	}
+
+	// Registrations used to be unique across the deployment; they are now
+	// unique per tenant, through idx_cars_tenant_registration.
+	if db.Migrator().HasIndex(&model.Car{}, "registration") {
+		db.Migrator().DropIndex(&model.Car{}, "registration")
+	}
+
+	// Car search selects its candidates with a full-text index on MySQL;
+	// other databases fall back to search.MemoryIndex.
+	if db.Dialector.Name() == "mysql" && !db.Migrator().HasIndex(&model.Car{}, search.FullTextIndex) {
+		if err := db.Exec("CREATE FULLTEXT INDEX " + search.FullTextIndex + " ON cars (make, car_model)").Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func InitializeDB(dsn string, opts ...gorm.Option) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(dsn), append([]gorm.Option{&gorm.Config{}}, opts...)...)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Scope the queries made on behalf of a tenant to its rows.
+	if err := tenant.Register(db); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
+
+// PendingMigrations returns the tables MigrateDB has not created yet.
+func (s *ParkingLotService) PendingMigrations(ctx context.Context) []string {
+	var missing []string
+	migrator := s.DB.WithContext(ctx).Migrator()
+	for _, m := range models {
+		if !migrator.HasTable(m) {
+			stmt := &gorm.Statement{DB: s.DB}
+			stmt.Parse(m)
+			missing = append(missing, stmt.Table)
+		}
+	}
+
+	return missing
+}
+
+// Ping checks that the database answers.
+func (s *ParkingLotService) Ping(ctx context.Context) error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
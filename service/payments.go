@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPaymentMethodRequired = errors.New("a payment method is required for the deposit")
+	ErrNothingToRefund       = errors.New("rental has no captured payment to refund")
+	ErrRefundExceedsCapture  = errors.New("refund exceeds the captured amount")
+)
+
+// payments reports whether rentals hold a deposit.
+func (s *ParkingLotService) payments() bool {
+	return s.Payments != nil && s.Deposit > 0
+}
+
+// authorizeDeposit holds the deposit of a rental about to be opened. It is
+// called before the transaction of the rental, so that no rows are locked
+// while the provider answers, and returns the reference of the
+// authorization, which the caller must release if the rental is not
+// committed after all.
+func (s *ParkingLotService) authorizeDeposit(ctx context.Context, opts RentOptions) (string, error) {
+	if !s.payments() {
+		return "", nil
+	}
+	if opts.PaymentMethod == "" {
+		return "", ErrPaymentMethodRequired
+	}
+
+	return s.Payments.Authorize(ctx, opts.PaymentMethod, s.Deposit)
+}
+
+// recordDeposit records the authorization of the deposit of a rental that
+// has just been opened, inside its transaction.
+func (s *ParkingLotService) recordDeposit(tx *gorm.DB, rental *model.Rental, reference string) error {
+	if reference == "" {
+		return nil
+	}
+
+	rental.Deposit = s.Deposit
+	if err := tx.Model(rental).Update("deposit", rental.Deposit).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.Payment{
+		RentalID:  rental.ID,
+		Kind:      model.PaymentAuthorization,
+		Status:    model.PaymentSucceeded,
+		Amount:    rental.Deposit,
+		Provider:  s.Payments.Name(),
+		Reference: reference,
+	}).Error
+}
+
+// releaseDeposit cancels the authorization of a rental that failed to
+// commit, returning err with any failure to do so.
+func (s *ParkingLotService) releaseDeposit(ctx context.Context, reference string, err error) error {
+	if reference == "" {
+		return err
+	}
+	return errors.Join(err, s.Payments.Release(context.WithoutCancel(ctx), reference))
+}
+
+// settleDeposit records the capture of the final amount of a returned
+// rental from its deposit as pending, in the transaction of the return. The
+// provider is only called by captureDeposit once the return is committed, so
+// a return rolled back captures nothing.
+func (s *ParkingLotService) settleDeposit(tx *gorm.DB, rental *model.Rental) (*model.Payment, error) {
+	if s.Payments == nil {
+		return nil, nil
+	}
+
+	var authorization model.Payment
+	err := tx.Where("rental_id = ? AND kind = ? AND status = ?", rental.ID, model.PaymentAuthorization, model.PaymentSucceeded).
+		First(&authorization).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	capture := model.Payment{
+		RentalID:      rental.ID,
+		Kind:          model.PaymentCapture,
+		Status:        model.PaymentPending,
+		Amount:        math.Min(rental.Amount, authorization.Amount),
+		Provider:      authorization.Provider,
+		NextAttemptAt: &now,
+	}
+	return &capture, tx.Create(&capture).Error
+}
+
+// CaptureDeposits makes the pending captures that are due: those that
+// failed before, or whose return was committed by a process that stopped
+// before making them. It returns the number of captures that succeeded.
+func (s *ParkingLotService) CaptureDeposits(ctx context.Context) (int, error) {
+	if s.Payments == nil {
+		return 0, nil
+	}
+
+	var captures []model.Payment
+	err := s.DB.WithContext(ctx).
+		Where("kind = ? AND status = ? AND next_attempt_at <= ?", model.PaymentCapture, model.PaymentPending, time.Now()).
+		Order("next_attempt_at").Limit(100).Find(&captures).Error
+	if err != nil {
+		return 0, err
+	}
+
+	captured := 0
+	for i := range captures {
+		if err := s.captureDeposit(ctx, &captures[i]); err != nil {
+			return captured, err
+		}
+		if captures[i].Status == model.PaymentSucceeded {
+			captured++
+		}
+	}
+	return captured, nil
+}
+
+// captureLease is how long a capture being made is hidden from other
+// processes.
+const captureLease = 5 * time.Minute
+
+// captureDeposit makes a pending capture. On success the rest of the deposit
+// is released and what it did not cover recorded as a balance due. A failed
+// capture is retried by CaptureDeposits, with a growing delay, until
+// CaptureAttempts; then the deposit is released and the whole amount is due.
+// The car is back whatever the provider says.
+func (s *ParkingLotService) captureDeposit(ctx context.Context, capture *model.Payment) error {
+	db := s.DB.WithContext(ctx)
+
+	// Lease the capture so that another process does not make it too.
+	now := time.Now()
+	lease := now.Add(captureLease)
+	result := db.Model(&model.Payment{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", capture.ID, model.PaymentPending, now).
+		Update("next_attempt_at", lease)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var rental model.Rental
+	if err := db.First(&rental, capture.RentalID).Error; err != nil {
+		return err
+	}
+	var authorization model.Payment
+	err := db.Where("rental_id = ? AND kind = ? AND status = ?", capture.RentalID, model.PaymentAuthorization, model.PaymentSucceeded).
+		First(&authorization).Error
+	if err != nil {
+		return err
+	}
+
+	reference, captureErr := s.Payments.Capture(ctx, authorization.Reference, capture.Amount)
+	capture.Attempts++
+	capture.NextAttemptAt = nil
+	captured := capture.Amount
+
+	var payments []model.Payment
+	switch {
+	case captureErr == nil:
+		capture.Status = model.PaymentSucceeded
+		capture.Reference = reference
+		capture.Error = ""
+		if released := authorization.Amount - captured; released > 0 {
+			payments = append(payments, model.Payment{
+				Kind:      model.PaymentRelease,
+				Status:    model.PaymentSucceeded,
+				Amount:    released,
+				Provider:  authorization.Provider,
+				Reference: authorization.Reference,
+			})
+		}
+	case capture.Attempts < s.CaptureAttempts:
+		capture.Error = captureErr.Error()
+		next := now.Add(time.Duration(1<<(capture.Attempts-1)) * time.Minute)
+		capture.NextAttemptAt = &next
+		return db.Save(capture).Error
+	default:
+		// Give up: free the customer's card and claim the amount instead.
+		capture.Status = model.PaymentFailed
+		capture.Error = captureErr.Error()
+		captured = 0
+		release := model.Payment{
+			Kind:      model.PaymentRelease,
+			Status:    model.PaymentSucceeded,
+			Amount:    authorization.Amount,
+			Provider:  authorization.Provider,
+			Reference: authorization.Reference,
+		}
+		if err := s.Payments.Release(ctx, authorization.Reference); err != nil {
+			release.Status = model.PaymentFailed
+			release.Error = err.Error()
+		}
+		payments = append(payments, release)
+	}
+	if balance := rental.Amount - captured; balance > 0 {
+		payments = append(payments, model.Payment{
+			Kind:   model.PaymentBalance,
+			Status: model.PaymentDue,
+			Amount: balance,
+		})
+	}
+
+	// Background runs have no tenant: the records take the one of the rental.
+	for i := range payments {
+		payments[i].TenantID = capture.TenantID
+		payments[i].RentalID = capture.RentalID
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(capture).Error; err != nil {
+			return err
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+		return tx.Create(&payments).Error
+	})
+}
+
+// RentalPayments returns the payments of a rental, oldest first.
+func (s *ParkingLotService) RentalPayments(ctx context.Context, id uint) ([]model.Payment, error) {
+	if _, err := s.GetRental(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var payments []model.Payment
+	err := s.DB.WithContext(ctx).Where("rental_id = ?", id).Order("id").Find(&payments).Error
+	return payments, err
+}
+
+// RefundRental gives back amount of the payment captured for a rental. The
+// refunds of a rental may not exceed its capture: its row is locked while
+// they are added up and the refund is made and recorded, so that concurrent
+// refunds are made one after the other.
+func (s *ParkingLotService) RefundRental(ctx context.Context, id uint, amount float64, reason string) (model.Payment, error) {
+	if _, err := s.GetRental(ctx, id); err != nil {
+		return model.Payment{}, err
+	}
+	if s.Payments == nil {
+		return model.Payment{}, ErrNothingToRefund
+	}
+
+	var refund model.Payment
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var capture model.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("rental_id = ? AND kind = ? AND status = ?", id, model.PaymentCapture, model.PaymentSucceeded).
+			First(&capture).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNothingToRefund
+		}
+		if err != nil {
+			return err
+		}
+
+		var refunded float64
+		err = tx.Model(&model.Payment{}).
+			Where("rental_id = ? AND kind = ? AND status = ?", id, model.PaymentRefund, model.PaymentSucceeded).
+			Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error
+		if err != nil {
+			return err
+		}
+		if amount > capture.Amount-refunded {
+			return ErrRefundExceedsCapture
+		}
+
+		reference, err := s.Payments.Refund(ctx, capture.Reference, amount)
+		if err != nil {
+			return err
+		}
+
+		refund = model.Payment{
+			RentalID:  id,
+			Kind:      model.PaymentRefund,
+			Status:    model.PaymentSucceeded,
+			Amount:    amount,
+			Provider:  capture.Provider,
+			Reference: reference,
+			Reason:    reason,
+		}
+		return tx.Create(&refund).Error
+	})
+
+	return refund, err
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"math"
+	"time"
+)
+
+// rentalPrice charges the daily rate for every started day, with a minimum of one.
+func rentalPrice(dailyRate float64, start, end time.Time) float64 {
+	days := math.Max(math.Ceil(end.Sub(start).Hours()/24), 1)
+	return days * dailyRate
+}
+
+// ReturnPolicy prices rentals returned before or after their planned end.
+type ReturnPolicy struct {
+	// LateRateMultiplier applies to the daily rate of the days after the
+	// planned end; 1 charges them at the daily rate.
+	LateRateMultiplier float64
+	// Grace is how late a car may be returned without paying late days.
+	Grace time.Duration
+}
+
+// DefaultReturnPolicy returns the policy applied unless configured otherwise.
+func DefaultReturnPolicy() ReturnPolicy {
+	return ReturnPolicy{LateRateMultiplier: 1.5, Grace: time.Hour}
+}
+
+// price charges a rental returned at end. Early returns only pay the days
+// used, returns within the grace period the planned days, and late returns
+// the late fee on top of the planned days.
+func (p ReturnPolicy) price(dailyRate float64, start time.Time, plannedEnd *time.Time, end time.Time) float64 {
+	if plannedEnd == nil || !end.After(*plannedEnd) {
+		return rentalPrice(dailyRate, start, end)
+	}
+	return rentalPrice(dailyRate, start, *plannedEnd) + p.lateFee(dailyRate, *plannedEnd, end)
+}
+
+// lateFee charges every started day after the planned end at the late rate,
+// once the grace period is over.
+func (p ReturnPolicy) lateFee(dailyRate float64, plannedEnd, end time.Time) float64 {
+	if !end.After(plannedEnd.Add(p.Grace)) {
+		return 0
+	}
+	late := math.Ceil(end.Sub(plannedEnd).Hours() / 24)
+	return late * dailyRate * p.LateRateMultiplier
+}
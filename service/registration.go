@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// ErrRegistrationCollisions is returned by MigrateDB while cars of a tenant
+// share a canonical registration, which its unique index forbids.
+var ErrRegistrationCollisions = errors.New("registrations are shared by several cars")
+
+// RegistrationCollision lists the cars of a tenant whose registrations only
+// differ by case, separators or look-alike letters. They were added before
+// registrations were compared in their canonical form and must be merged or
+// corrected by hand: lookups by registration find only one of them.
+type RegistrationCollision struct {
+	TenantID              string   `json:"tenant_id"`
+	CanonicalRegistration string   `json:"canonical_registration"`
+	Registrations         []string `json:"registrations"`
+}
+
+// prepareCanonicalRegistrations adds the tenant and canonical registration
+// columns to an existing cars table and fills them in, then checks that
+// canonical registrations can be made unique within each tenant. An index
+// created before it was unique is dropped for AutoMigrate to create again.
+func prepareCanonicalRegistrations(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&model.Car{}) {
+		return nil
+	}
+	// Cars added before tenants existed belong to the default tenant, which
+	// the column gives them as it is added.
+	for _, field := range []string{"TenantID", "CanonicalRegistration"} {
+		if !migrator.HasColumn(&model.Car{}, field) {
+			if err := migrator.AddColumn(&model.Car{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	if err := backfillCanonicalRegistrations(db); err != nil {
+		return err
+	}
+
+	indexes, err := migrator.GetIndexes(&model.Car{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if unique, _ := index.Unique(); index.Name() == canonicalIndex && !unique {
+			if err := migrator.DropIndex(&model.Car{}, canonicalIndex); err != nil {
+				return err
+			}
+		}
+	}
+	if migrator.HasIndex(&model.Car{}, canonicalIndex) {
+		return nil
+	}
+
+	collisions, err := RegistrationCollisions(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if len(collisions) > 0 {
+		return ErrRegistrationCollisions
+	}
+	return nil
+}
+
+// canonicalIndex makes canonical registrations unique within a tenant.
+const canonicalIndex = "idx_cars_tenant_canonical_registration"
+
+// backfillCanonicalRegistrations computes the canonical registration of the
+// cars added before it was stored, deleted ones included.
+func backfillCanonicalRegistrations(db *gorm.DB) error {
+	var cars []model.Car
+	return db.Unscoped().Select("id", "registration").
+		Where("canonical_registration IS NULL OR canonical_registration = ''").
+		FindInBatches(&cars, 500, func(tx *gorm.DB, batch int) error {
+			for _, car := range cars {
+				err := db.Unscoped().Model(&car).
+					UpdateColumn("canonical_registration", model.CanonicalRegistration(car.Registration)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// RegistrationCollisions returns the groups of cars sharing a canonical
+// registration within their tenant, deleted ones included.
+func RegistrationCollisions(ctx context.Context, db *gorm.DB) ([]RegistrationCollision, error) {
+	type group struct {
+		TenantID              string
+		CanonicalRegistration string
+	}
+	var groups []group
+	err := db.WithContext(ctx).Unscoped().Model(&model.Car{}).
+		Select("tenant_id", "canonical_registration").
+		Group("tenant_id, canonical_registration").
+		Having("COUNT(*) > 1").
+		Order("tenant_id, canonical_registration").
+		Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+
+	collisions := make([]RegistrationCollision, 0, len(groups))
+	for _, g := range groups {
+		var registrations []string
+		err := db.WithContext(ctx).Unscoped().Model(&model.Car{}).
+			Where("tenant_id = ? AND canonical_registration = ?", g.TenantID, g.CanonicalRegistration).
+			Order("id").
+			Pluck("registration", &registrations).Error
+		if err != nil {
+			return nil, err
+		}
+		collisions = append(collisions, RegistrationCollision{
+			TenantID:              g.TenantID,
+			CanonicalRegistration: g.CanonicalRegistration,
+			Registrations:         registrations,
+		})
+	}
+	return collisions, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// RentalFilter holds the optional criteria used to list rentals. Zero values
+// are ignored.
+type RentalFilter struct {
+	CarID       uint
+	CustomerID  uint
+	Open        *bool
+	NeedsReview *bool
+	AfterID     uint
+	Limit       int
+}
+
+// ListRentals returns the rentals matching the filter, ordered by id.
+func (s *ParkingLotService) ListRentals(ctx context.Context, filter RentalFilter) ([]model.Rental, error) {
+	query := s.DB.WithContext(ctx).Order("id")
+
+	if filter.CarID > 0 {
+		query = query.Where("car_id = ?", filter.CarID)
+	}
+	if filter.CustomerID > 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Open != nil {
+		if *filter.Open {
+			query = query.Where("ended_at IS NULL")
+		} else {
+			query = query.Where("ended_at IS NOT NULL")
+		}
+	}
+	if filter.NeedsReview != nil {
+		query = query.Where("needs_review = ?", *filter.NeedsReview)
+	}
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var rentals []model.Rental
+	err := query.Find(&rentals).Error
+	return rentals, err
+}
+
+// GetRental returns the rental with the given id.
+func (s *ParkingLotService) GetRental(ctx context.Context, id uint) (model.Rental, error) {
+	var rental model.Rental
+	err := s.DB.WithContext(ctx).First(&rental, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rental, ErrRentalNotFound
+	}
+	return rental, err
+}
+
+// CarsByID loads the cars with the given ids in one query, including deleted
+// cars still referenced by their rentals.
+func (s *ParkingLotService) CarsByID(ctx context.Context, ids []uint) (map[uint]model.Car, error) {
+	cars := make(map[uint]model.Car, len(ids))
+	if len(ids) == 0 {
+		return cars, nil
+	}
+
+	var rows []model.Car
+	if err := s.DB.WithContext(ctx).Unscoped().Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, car := range rows {
+		cars[car.ID] = car
+	}
+	return cars, nil
+}
+
+// RentalsByCar loads the latest rentals of the given cars in one query, at
+// most first of each, most recent first.
+func (s *ParkingLotService) RentalsByCar(ctx context.Context, carIDs []uint, first int) (map[uint][]model.Rental, error) {
+	rentals := make(map[uint][]model.Rental, len(carIDs))
+	if len(carIDs) == 0 || first <= 0 {
+		return rentals, nil
+	}
+
+	// Number the rentals of each car so that only the first ones are read,
+	// however long the history of the car.
+	db := s.DB.WithContext(ctx)
+	ranked := db.Model(&model.Rental{}).
+		Select("rentals.*, ROW_NUMBER() OVER (PARTITION BY car_id ORDER BY started_at DESC, id DESC) AS position").
+		Where("car_id IN ?", carIDs)
+	var rows []model.Rental
+	err := db.Table("(?) AS ranked", ranked).Where("position <= ?", first).Order("started_at DESC, id DESC").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, rental := range rows {
+		rentals[rental.CarID] = append(rentals[rental.CarID], rental)
+	}
+	return rentals, nil
+}
+
+// RentalsByCustomer loads the rentals of the given customers in one query,
+// most recent first.
+func (s *ParkingLotService) RentalsByCustomer(ctx context.Context, customerIDs []uint) (map[uint][]model.Rental, error) {
+	return s.rentalsBy(ctx, "customer_id", customerIDs, func(rental model.Rental) uint { return *rental.CustomerID })
+}
+
+// rentalsBy groups the rentals whose column is in ids by key.
+func (s *ParkingLotService) rentalsBy(ctx context.Context, column string, ids []uint, key func(model.Rental) uint) (map[uint][]model.Rental, error) {
+	rentals := make(map[uint][]model.Rental, len(ids))
+	if len(ids) == 0 {
+		return rentals, nil
+	}
+
+	var rows []model.Rental
+	if err := s.DB.WithContext(ctx).Where(column+" IN ?", ids).Order("started_at DESC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, rental := range rows {
+		rentals[key(rental)] = append(rentals[key(rental)], rental)
+	}
+	return rentals, nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/tenant"
+)
+
+// ReportRange is the half-open period [From, To) a report is computed over.
+type ReportRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r ReportRange) seconds() float64 {
+	return r.To.Sub(r.From).Seconds()
+}
+
+// UtilizationRow is the share of a period during which a car, or all cars of
+// a model, were out on rental.
+type UtilizationRow struct {
+	CarID        uint    `json:"car_id,omitempty"`
+	Registration string  `json:"registration,omitempty"`
+	CarModel     string  `json:"model"`
+	Cars         int     `json:"cars"`
+	Rentals      int     `json:"rentals"`
+	RentedHours  float64 `json:"rented_hours"`
+	Utilization  float64 `json:"utilization"`
+}
+
+// RentalStats summarizes the rentals that ended during a period.
+type RentalStats struct {
+	Rentals              int     `json:"rentals"`
+	AverageDurationHours float64 `json:"average_duration_hours"`
+	Kilometers           float64 `json:"kilometers"`
+	Revenue              float64 `json:"revenue"`
+}
+
+// IdleCarRow is an available car that has not been rented for a while.
+type IdleCarRow struct {
+	CarID        uint       `json:"car_id"`
+	Registration string     `json:"registration"`
+	CarModel     string     `json:"model"`
+	LastRentedAt *time.Time `json:"last_rented_at"`
+	IdleDays     int        `json:"idle_days"`
+}
+
+// RevenueRow is the money earned by a car from rentals ended during a period.
+type RevenueRow struct {
+	CarID        uint    `json:"car_id"`
+	Registration string  `json:"registration"`
+	CarModel     string  `json:"model"`
+	Rentals      int     `json:"rentals"`
+	Kilometers   float64 `json:"kilometers"`
+	Revenue      float64 `json:"revenue"`
+}
+
+// tenantOf returns the tenant the reports of ctx are restricted to, or an
+// empty string for every tenant. Raw SQL is not scoped by the tenant
+// callbacks, so each report filters on it with @tenant.
+func tenantOf(ctx context.Context) string {
+	id, _ := tenant.FromContext(ctx)
+	return id
+}
+
+// rentedSeconds is the part of each rental overlapping the report range;
+// rentals still open count until the end of the range.
+const rentedSeconds = `COALESCE(SUM(TIMESTAMPDIFF(SECOND,
+	GREATEST(r.started_at, @from),
+	LEAST(COALESCE(r.ended_at, @to), @to))), 0)`
+
+// UtilizationByCar computes, for every car, the share of the range it spent rented.
+func (s *ParkingLotService) UtilizationByCar(ctx context.Context, period ReportRange) ([]UtilizationRow, error) {
+	var rows []UtilizationRow
+	err := s.DB.WithContext(ctx).Raw(`
+		SELECT c.id AS car_id, c.registration, c.car_model, 1 AS cars,
+			COUNT(r.id) AS rentals,
+			`+rentedSeconds+` / 3600 AS rented_hours,
+			`+rentedSeconds+` / @seconds AS utilization
+		FROM cars c
+		LEFT JOIN rentals r ON r.car_id = c.id AND r.started_at < @to AND (r.ended_at IS NULL OR r.ended_at > @from)
+		WHERE c.deleted_at IS NULL AND (@tenant = '' OR c.tenant_id = @tenant)
+		GROUP BY c.id, c.registration, c.car_model
+		ORDER BY utilization DESC, c.registration`,
+		map[string]interface{}{"from": period.From, "to": period.To, "seconds": period.seconds(), "tenant": tenantOf(ctx)},
+	).Scan(&rows).Error
+
+	return rows, err
+}
+
+// UtilizationByModel computes, for every car model, the share of the range
+// its cars spent rented.
+func (s *ParkingLotService) UtilizationByModel(ctx context.Context, period ReportRange) ([]UtilizationRow, error) {
+	var rows []UtilizationRow
+	err := s.DB.WithContext(ctx).Raw(`
+		SELECT c.car_model, COUNT(DISTINCT c.id) AS cars,
+			COUNT(r.id) AS rentals,
+			`+rentedSeconds+` / 3600 AS rented_hours,
+			`+rentedSeconds+` / (@seconds * COUNT(DISTINCT c.id)) AS utilization
+		FROM cars c
+		LEFT JOIN rentals r ON r.car_id = c.id AND r.started_at < @to AND (r.ended_at IS NULL OR r.ended_at > @from)
+		WHERE c.deleted_at IS NULL AND (@tenant = '' OR c.tenant_id = @tenant)
+		GROUP BY c.car_model
+		ORDER BY utilization DESC, c.car_model`,
+		map[string]interface{}{"from": period.From, "to": period.To, "seconds": period.seconds(), "tenant": tenantOf(ctx)},
+	).Scan(&rows).Error
+
+	return rows, err
+}
+
+// RentalStatistics computes the count, average duration, distance and
+// revenue of the rentals that ended during the range.
+func (s *ParkingLotService) RentalStatistics(ctx context.Context, period ReportRange) (RentalStats, error) {
+	var stats RentalStats
+	err := s.DB.WithContext(ctx).Raw(`
+		SELECT COUNT(*) AS rentals,
+			COALESCE(AVG(TIMESTAMPDIFF(SECOND, started_at, ended_at)), 0) / 3600 AS average_duration_hours,
+			COALESCE(SUM(distance), 0) AS kilometers,
+			COALESCE(SUM(amount), 0) AS revenue
+		FROM rentals
+		WHERE ended_at >= @from AND ended_at < @to AND (@tenant = '' OR tenant_id = @tenant)`,
+		map[string]interface{}{"from": period.From, "to": period.To, "tenant": tenantOf(ctx)},
+	).Scan(&stats).Error
+
+	return stats, err
+}
+
+// IdleCars lists the available cars that have not been rented since the given time.
+func (s *ParkingLotService) IdleCars(ctx context.Context, since time.Time) ([]IdleCarRow, error) {
+	var rows []IdleCarRow
+	err := s.DB.WithContext(ctx).Raw(`
+		SELECT c.id AS car_id, c.registration, c.car_model, c.last_rented_at,
+			TIMESTAMPDIFF(DAY, COALESCE(c.last_rented_at, c.created_at), NOW()) AS idle_days
+		FROM cars c
+		WHERE c.deleted_at IS NULL AND c.status = @status
+			AND COALESCE(c.last_rented_at, c.created_at) < @since
+			AND (@tenant = '' OR c.tenant_id = @tenant)
+		ORDER BY idle_days DESC, c.registration`,
+		map[string]interface{}{"status": model.StatusAvailable, "since": since, "tenant": tenantOf(ctx)},
+	).Scan(&rows).Error
+
+	return rows, err
+}
+
+// RevenueByCar computes the revenue and distance of every car from the
+// rentals that ended during the range.
+func (s *ParkingLotService) RevenueByCar(ctx context.Context, period ReportRange) ([]RevenueRow, error) {
+	var rows []RevenueRow
+	err := s.DB.WithContext(ctx).Raw(`
+		SELECT c.id AS car_id, c.registration, c.car_model,
+			COUNT(r.id) AS rentals,
+			COALESCE(SUM(r.distance), 0) AS kilometers,
+			COALESCE(SUM(r.amount), 0) AS revenue
+		FROM cars c
+		LEFT JOIN rentals r ON r.car_id = c.id AND r.ended_at >= @from AND r.ended_at < @to
+		WHERE c.deleted_at IS NULL AND (@tenant = '' OR c.tenant_id = @tenant)
+		GROUP BY c.id, c.registration, c.car_model
+		ORDER BY revenue DESC, c.registration`,
+		map[string]interface{}{"from": period.From, "to": period.To, "tenant": tenantOf(ctx)},
+	).Scan(&rows).Error
+
+	return rows, err
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/notify"
+	"github.com/abdeel07/backend-go-cars/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationConflict = errors.New("car is reserved")
+	ErrInvalidPeriod       = errors.New("period must end after it starts, in the future")
+	ErrRentalClosed        = errors.New("rental is closed")
+	ErrRentedDuringPeriod  = errors.New("car is rented during the period")
+)
+
+// ReservationConflictError is returned when a rental or reservation overlaps
+// a reservation of the car.
+type ReservationConflictError struct {
+	Reservation model.Reservation
+}
+
+func (e *ReservationConflictError) Error() string {
+	return fmt.Sprintf("car is reserved from %s to %s",
+		e.Reservation.StartsAt.Format(time.RFC3339), e.Reservation.EndsAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrReservationConflict) match every
+// ReservationConflictError.
+func (e *ReservationConflictError) Is(target error) bool {
+	return target == ErrReservationConflict
+}
+
+// checkReservations returns a ReservationConflictError if a reservation of
+// the car overlaps the period from start to end. The reservations of the
+// customer, if any, do not conflict: they are the ones being used. The car
+// row stays locked until tx ends, so that concurrent rentals, reservations
+// and extensions of the car cannot all pass the check.
+func checkReservations(tx *gorm.DB, carID uint, customerID *uint, start, end time.Time) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.Car{}, carID).Error; err != nil {
+		return err
+	}
+
+	query := tx.Where("car_id = ? AND cancelled_at IS NULL AND starts_at < ? AND ends_at > ?", carID, end, start)
+	if customerID != nil {
+		query = query.Where("customer_id IS NULL OR customer_id <> ?", *customerID)
+	}
+
+	var reservation model.Reservation
+	err := query.Order("starts_at").First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &ReservationConflictError{Reservation: reservation}
+}
+
+// CreateReservation books a car over a future period that overlaps neither
+// its other reservations nor its rental in progress, which blocks the car
+// until its planned end or, without one, until it is returned.
+func (s *ParkingLotService) CreateReservation(ctx context.Context, registration string, reservation *model.Reservation) error {
+	if !reservation.EndsAt.After(reservation.StartsAt) || !reservation.EndsAt.After(time.Now()) {
+		return ErrInvalidPeriod
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		car, err := findCar(tx, registration)
+		if err != nil {
+			return err
+		}
+		if reservation.CustomerID != nil {
+			if _, err := findCustomer(tx, *reservation.CustomerID); err != nil {
+				return err
+			}
+		}
+
+		if err := checkReservations(tx, car.ID, nil, reservation.StartsAt, reservation.EndsAt); err != nil {
+			return err
+		}
+
+		var rental model.Rental
+		err = tx.Where("car_id = ? AND ended_at IS NULL AND (planned_end_at IS NULL OR planned_end_at > ?)", car.ID, reservation.StartsAt).
+			First(&rental).Error
+		if err == nil {
+			return ErrRentedDuringPeriod
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		reservation.ID = 0
+		reservation.CarID = car.ID
+		reservation.CancelledAt = nil
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+
+		return s.notifyCustomer(tx, model.NotifyBookingConfirmation, reservation.CustomerID, notify.Data{Car: car, Reservation: reservation})
+	})
+}
+
+// CarReservations returns the reservations of a car that have not ended or
+// been cancelled, soonest first.
+func (s *ParkingLotService) CarReservations(ctx context.Context, registration string) ([]model.Reservation, error) {
+	car, err := findCar(s.DB.WithContext(ctx), registration)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservations []model.Reservation
+	err = s.DB.WithContext(ctx).
+		Where("car_id = ? AND cancelled_at IS NULL AND ends_at > ?", car.ID, time.Now()).
+		Order("starts_at").Find(&reservations).Error
+	return reservations, err
+}
+
+// CancelReservation releases a reservation.
+func (s *ParkingLotService) CancelReservation(ctx context.Context, id uint) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := s.DB.WithContext(ctx).Where("cancelled_at IS NULL").First(&reservation, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reservation, ErrReservationNotFound
+	}
+	if err != nil {
+		return reservation, err
+	}
+
+	now := time.Now()
+	reservation.CancelledAt = &now
+	return reservation, s.DB.WithContext(ctx).Model(&reservation).Update("cancelled_at", now).Error
+}
+
+// ExtendRental moves the planned end of a rental in progress later, as long
+// as the car is not reserved in the meantime, and quotes its new price.
+func (s *ParkingLotService) ExtendRental(ctx context.Context, id uint, plannedEnd time.Time) (model.Rental, error) {
+	var rental model.Rental
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&rental, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRentalNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if rental.EndedAt != nil {
+			return ErrRentalClosed
+		}
+
+		// The extension starts at the current planned end, or now for
+		// rentals without one.
+		from := time.Now()
+		if rental.PlannedEndAt != nil && rental.PlannedEndAt.After(from) {
+			from = *rental.PlannedEndAt
+		}
+		if !plannedEnd.After(from) {
+			return ErrInvalidPeriod
+		}
+		if err := checkReservations(tx, rental.CarID, rental.CustomerID, from, plannedEnd); err != nil {
+			return err
+		}
+
+		rental.PlannedEndAt = &plannedEnd
+		rental.PlannedAmount = rentalPrice(rental.DailyRate+rental.YoungDriverSurcharge, rental.StartedAt, plannedEnd)
+		// An overdue rental extended into the future is no longer late.
+		rental.Overdue = false
+		rental.OverdueAt = nil
+		rental.LateFee = 0
+		return tx.Model(&rental).Select("planned_end_at", "planned_amount", "overdue", "overdue_at", "late_fee").Updates(&rental).Error
+	})
+
+	return rental, err
+}
+
+// RemindPickups notifies the customers whose reservation starts within
+// ReminderLead that their car is waiting, once per reservation, and returns how many were
+// reminded. It runs across tenants unless ctx carries one.
+func (s *ParkingLotService) RemindPickups(ctx context.Context) (int, error) {
+	now := time.Now()
+	var reservations []model.Reservation
+	err := s.DB.WithContext(ctx).
+		Where("cancelled_at IS NULL AND reminded_at IS NULL AND customer_id IS NOT NULL AND starts_at > ? AND starts_at <= ?",
+			now, now.Add(s.ReminderLead)).
+		Order("starts_at").Find(&reservations).Error
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := 0
+	for _, reservation := range reservations {
+		// The reminder is queued by the tenant of the reservation.
+		reservationCtx := tenant.WithID(ctx, reservation.TenantID)
+		err := s.DB.WithContext(reservationCtx).Transaction(func(tx *gorm.DB) error {
+			update := tx.Model(&reservation).Where("reminded_at IS NULL AND cancelled_at IS NULL").Update("reminded_at", now)
+			if update.Error != nil || update.RowsAffected == 0 {
+				return update.Error
+			}
+
+			var car model.Car
+			if err := tx.Unscoped().First(&car, reservation.CarID).Error; err != nil {
+				return err
+			}
+			err := s.Notifications.Enqueue(tx, model.NotifyPickupReminder, *reservation.CustomerID,
+				notify.Data{Car: car, Reservation: &reservation})
+			if err == nil {
+				reminded++
+			}
+			return err
+		})
+		if err != nil {
+			return reminded, err
+		}
+	}
+
+	return reminded, nil
+}
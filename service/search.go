@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"github.com/abdeel07/backend-go-cars/search"
+)
+
+// CarMatch is a car found by SearchCars.
+type CarMatch struct {
+	Car     model.Car    `json:"car"`
+	Score   float64      `json:"score"`
+	Matched search.Field `json:"matched"`
+}
+
+// SearchCars returns the cars whose registration or model resembles the
+// query, best match first.
+func (s *ParkingLotService) SearchCars(ctx context.Context, query string, limit int) ([]CarMatch, error) {
+	hits, err := s.Search.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return []CarMatch{}, nil
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var cars []model.Car
+	if err := s.DB.WithContext(ctx).Where("id IN ?", ids).Find(&cars).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.Car, len(cars))
+	for _, car := range cars {
+		byID[car.ID] = car
+	}
+
+	// Keep the order of the hits, skipping cars deleted meanwhile.
+	matches := make([]CarMatch, 0, len(hits))
+	for _, hit := range hits {
+		if car, ok := byID[hit.ID]; ok {
+			matches = append(matches, CarMatch{Car: car, Score: hit.Score, Matched: hit.Field})
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+var ErrVersionMismatch = errors.New("car was modified since it was read")
+
+type ifMatchKey struct{}
+
+// WithIfMatch returns a context in which the changes to a car fail with
+// ErrVersionMismatch unless the car is at one of the given versions. An empty
+// list matches no version.
+func WithIfMatch(ctx context.Context, versions []uint) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, versions)
+}
+
+// checkVersion applies the versions expected by WithIfMatch, if any.
+func checkVersion(ctx context.Context, car model.Car) error {
+	versions, ok := ctx.Value(ifMatchKey{}).([]uint)
+	if !ok {
+		return nil
+	}
+	for _, version := range versions {
+		if version == car.Version {
+			return nil
+		}
+	}
+	return ErrVersionMismatch
+}
+
+// updatableColumns are the descriptive columns changed by UpdateCar. The
+// registration, status and mileage have their own flows.
+var updatableColumns = []string{
+	"car_model", "make", "year", "vin", "colour", "seats", "transmission", "fuel_type",
+	"purchase_date", "purchase_price", "category", "branch", "next_service_mileage", "daily_rate",
+}
+
+// UpdateCar applies update to the descriptive fields of a car and saves them,
+// unless the car changed meanwhile. update may reject the change by returning
+// an error, which UpdateCar returns unchanged.
+func (s *ParkingLotService) UpdateCar(ctx context.Context, registration string, update func(car *model.Car) error) (model.Car, error) {
+	var car model.Car
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if car, err = findCar(tx, registration); err != nil {
+			return err
+		}
+
+		version, stored := car.Version, car.Registration
+		if err := update(&car); err != nil {
+			return err
+		}
+		car.Registration = stored
+
+		if car.VIN != "" {
+			var count int64
+			if err := tx.Model(&model.Car{}).Where("vin = ? AND id <> ?", car.VIN, car.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVINExists
+			}
+		}
+
+		car.Version = version + 1
+		columns := append([]string{"version"}, updatableColumns...)
+		result := tx.Model(&car).Where("version = ?", version).Select(columns).Updates(&car)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionMismatch
+		}
+
+		return emit(tx, model.EventCarUpdated, FleetEvent{Car: car})
+	})
+
+	return car, err
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrDeliveryNotDead      = errors.New("webhook delivery is not dead")
+)
+
+// CreateSubscription registers a webhook endpoint.
+func (s *ParkingLotService) CreateSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	return s.DB.WithContext(ctx).Create(subscription).Error
+}
+
+// ListSubscriptions returns every webhook subscription, without their secrets.
+func (s *ParkingLotService) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	if err := s.DB.WithContext(ctx).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes a webhook subscription and its pending deliveries.
+func (s *ParkingLotService) DeleteSubscription(ctx context.Context, id uint) error {
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrSubscriptionNotFound
+		}
+
+		return tx.Where("subscription_id = ? AND status = ?", id, model.DeliveryPending).
+			Delete(&model.WebhookDelivery{}).Error
+	})
+}
+
+// ListDeliveries returns the webhook deliveries in the given status, or all
+// of them when status is empty, most recent first.
+func (s *ParkingLotService) ListDeliveries(ctx context.Context, status model.DeliveryStatus) ([]model.WebhookDelivery, error) {
+	query := s.DB.WithContext(ctx).Preload("Event").Order("id DESC").Limit(500)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []model.WebhookDelivery
+	err := query.Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RetryDelivery puts a dead delivery back in the queue with a fresh set of
+// attempts. Deliveries that are pending or delivered, or of a deleted
+// subscription, cannot be retried.
+func (s *ParkingLotService) RetryDelivery(ctx context.Context, id uint) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := s.DB.WithContext(ctx).First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return delivery, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return delivery, err
+	}
+
+	if delivery.Status != model.DeliveryDead {
+		return delivery, ErrDeliveryNotDead
+	}
+
+	var subscriptions int64
+	err = s.DB.WithContext(ctx).Model(&model.WebhookSubscription{}).Where("id = ?", delivery.SubscriptionID).Count(&subscriptions).Error
+	if err != nil {
+		return delivery, err
+	}
+	if subscriptions == 0 {
+		return delivery, ErrSubscriptionNotFound
+	}
+
+	// A concurrent retry may have requeued the delivery meanwhile.
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	result := s.DB.WithContext(ctx).Model(&delivery).Where("status = ?", model.DeliveryDead).
+		Select("status", "attempts", "next_attempt_at").Updates(&delivery)
+	if result.Error != nil {
+		return delivery, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return delivery, ErrDeliveryNotDead
+	}
+	return delivery, nil
+}
@@ -0,0 +1,50 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// status maps resolution errors to HTTP statuses.
+func status(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidID):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMismatch), errors.Is(err, ErrHeader):
+		return http.StatusForbidden
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
+// Middleware resolves the tenant of each request and adds it to the request
+// context. Requests to the public route templates, such as the probes, are
+// served even when no tenant can be resolved.
+func (r *Resolver) Middleware(public map[string]bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id, err := r.Resolve(req.Header.Get("Authorization"), req.Header.Get(Header))
+			if err == nil {
+				next.ServeHTTP(w, req.WithContext(WithID(req.Context(), id)))
+				return
+			}
+
+			if route := mux.CurrentRoute(req); route != nil {
+				if template, _ := route.GetPathTemplate(); public[template] {
+					next.ServeHTTP(w, req)
+					return
+				}
+			}
+
+			if status(err) == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status(err))
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// column is the column holding the tenant of scoped tables.
+const column = "tenant_id"
+
+// Register installs the callbacks that scope the queries of db to the tenant
+// of their context: rows are created for that tenant, and reads, updates and
+// deletes only see its rows. Models without a tenant_id column, raw SQL and
+// contexts without a tenant are left alone.
+func Register(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("tenant:create", assign); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", restrict); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", restrict); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", restrict); err != nil {
+		return err
+	}
+	return callbacks.Row().Before("gorm:row").Register("tenant:row", restrict)
+}
+
+// scoped returns the tenant of the statement, if its model is scoped.
+func scoped(db *gorm.DB) (string, bool) {
+	id, ok := FromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField(column) == nil {
+		return "", false
+	}
+	return id, true
+}
+
+// assign sets the tenant of the rows being created.
+func assign(db *gorm.DB) {
+	id, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField(column)
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(db.Statement.Context, reflect.Indirect(value.Index(i)), id); err != nil {
+				db.AddError(err)
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(db.Statement.Context, value, id); err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// restrict adds the tenant condition to the statement.
+func restrict(db *gorm.DB) {
+	id, ok := scoped(db)
+	if !ok {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: id},
+	}})
+}
@@ -0,0 +1,126 @@
+// Package tenant isolates the franchises hosted on one deployment: it
+// resolves the tenant of each request and restricts the database queries made
+// on its behalf to the rows of that tenant.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Header names the tenant of a request when it is not derived from a token.
+const Header = "X-Tenant-ID"
+
+// DefaultID is the tenant of the rows created before tenants existed.
+const DefaultID = "default"
+
+var (
+	ErrInvalidToken = errors.New("invalid bearer token")
+	ErrInvalidID    = errors.New("tenant id must be 1 to 64 lowercase letters, digits, dashes or underscores")
+	ErrMismatch     = errors.New("tenant header does not match the token")
+	ErrRequired     = errors.New("a tenant is required")
+	ErrHeader       = errors.New("tenant header is not accepted, use a bearer token")
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidID reports whether id is a well-formed tenant id.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type contextKey struct{}
+
+// WithID returns a context whose database queries are restricted to the
+// given tenant.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// Without returns a context whose database queries see the rows of every
+// tenant, for work done on behalf of the whole deployment.
+func Without(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, nil)
+}
+
+// FromContext returns the tenant of ctx. Contexts without a tenant, such as
+// those of background jobs, see the rows of every tenant.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// Resolver finds the tenant of a request from its credentials.
+type Resolver struct {
+	// Tokens maps the bearer tokens accepted to their tenant.
+	Tokens map[string]string
+	// AllowHeader accepts the tenant named by the X-Tenant-ID header from
+	// requests without a token, when a gateway in front of the API has
+	// already authenticated them. It is ignored once Tokens are configured,
+	// since any client could then name the tenant of another.
+	AllowHeader bool
+	// Default is the tenant of requests with neither a token nor a header.
+	// Without one, such requests are refused, as they are once Tokens are
+	// configured: the default tenant is then no less private than the others.
+	Default string
+}
+
+// NewResolver creates a resolver refusing the header, with the default
+// tenant for anonymous requests.
+func NewResolver() *Resolver {
+	return &Resolver{Tokens: map[string]string{}, Default: DefaultID}
+}
+
+// Resolve returns the tenant for the Authorization and X-Tenant-ID values of
+// a request. A bearer token takes precedence; a header naming another tenant
+// than the token is refused.
+func (r *Resolver) Resolve(authorization, header string) (string, error) {
+	if authorization != "" {
+		token, ok := strings.CutPrefix(authorization, "Bearer ")
+		id, known := r.Tokens[strings.TrimSpace(token)]
+		if !ok || !known {
+			return "", ErrInvalidToken
+		}
+		if header != "" && header != id {
+			return "", ErrMismatch
+		}
+		return id, nil
+	}
+
+	if header != "" {
+		if !r.AllowHeader || len(r.Tokens) > 0 {
+			return "", ErrHeader
+		}
+		if !ValidID(header) {
+			return "", ErrInvalidID
+		}
+		return header, nil
+	}
+
+	if r.Default == "" || len(r.Tokens) > 0 {
+		return "", ErrRequired
+	}
+	return r.Default, nil
+}
+
+// ParseTokens parses a comma separated list of "token=tenant" pairs.
+func ParseTokens(text string) (map[string]string, error) {
+	tokens := map[string]string{}
+	for _, entry := range strings.Split(text, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		token, id, ok := strings.Cut(entry, "=")
+		if !ok || token == "" {
+			return nil, errors.New("invalid tenant token, expected token=tenant")
+		}
+		if !ValidID(id) {
+			return nil, ErrInvalidID
+		}
+		tokens[token] = id
+	}
+	return tokens, nil
+}
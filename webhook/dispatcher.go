@@ -0,0 +1,270 @@
+// Package webhook publishes the events of the outbox table to the webhook
+// subscriptions, with signed payloads and retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abdeel07/backend-go-cars/model"
+	"gorm.io/gorm"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// batchSize bounds the events and deliveries handled by one RunOnce.
+const batchSize = 100
+
+// Envelope is the JSON body POSTed to subscribers.
+type Envelope struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	TenantID  string          `json:"tenant_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign computes the signature of a delivery: the hex HMAC-SHA256, keyed with
+// the subscription secret, of the timestamp header, a dot and the body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher fans outbox events out to the matching subscriptions and sends
+// the deliveries, retrying failures with exponential backoff until
+// MaxAttempts, after which a delivery is dead.
+type Dispatcher struct {
+	DB *gorm.DB
+	// Client refuses private addresses, unless replaced by NewClient(true)
+	// for subscribers on the network of the deployment.
+	Client      *http.Client
+	Logger      *slog.Logger
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// NewDispatcher creates a dispatcher with default retry settings.
+func NewDispatcher(db *gorm.DB, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		DB:          db,
+		Client:      NewClient(false),
+		Logger:      logger,
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+	}
+}
+
+// Run calls RunOnce every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.RunOnce(ctx); err != nil {
+			d.Logger.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce fans out the new outbox events, then sends the deliveries that are due.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliver(ctx)
+}
+
+// fanOut creates one delivery per event and subscription. Events are only
+// sent to subscriptions of their tenant that existed when they happened.
+func (d *Dispatcher) fanOut(ctx context.Context) error {
+	var events []model.OutboxEvent
+	err := d.DB.WithContext(ctx).Where("dispatched_at IS NULL").Order("id").Limit(batchSize).Find(&events).Error
+	if err != nil || len(events) == 0 {
+		return err
+	}
+
+	var subscriptions []model.WebhookSubscription
+	if err := d.DB.WithContext(ctx).Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		err := d.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Claim the event so another replica does not fan it out too.
+			now := time.Now()
+			result := tx.Model(&model.OutboxEvent{}).
+				Where("id = ? AND dispatched_at IS NULL", event.ID).
+				Update("dispatched_at", now)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			for _, subscription := range subscriptions {
+				if subscription.TenantID != event.TenantID || !subscription.Subscribes(event.Type) ||
+					subscription.CreatedAt.After(event.CreatedAt) {
+					continue
+				}
+				delivery := model.WebhookDelivery{
+					TenantID:       subscription.TenantID,
+					SubscriptionID: subscription.ID,
+					EventID:        event.ID,
+					Status:         model.DeliveryPending,
+					NextAttemptAt:  now,
+				}
+				if err := tx.Create(&delivery).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliver sends the pending deliveries whose next attempt is due.
+func (d *Dispatcher) deliver(ctx context.Context) error {
+	var deliveries []model.WebhookDelivery
+	err := d.DB.WithContext(ctx).Preload("Event").
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, time.Now()).
+		Order("next_attempt_at").Limit(batchSize).Find(&deliveries).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		if err := d.attempt(ctx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt sends one delivery and records its outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	// Lease the delivery so another replica does not send it concurrently.
+	lease := time.Now().Add(d.Client.Timeout + time.Minute)
+	result := d.DB.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.DeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	// A delivery whose subscription was deleted has nowhere to go.
+	var subscription model.WebhookSubscription
+	err := d.DB.WithContext(ctx).First(&subscription, delivery.SubscriptionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		delivery.Status = model.DeliveryDead
+		delivery.LastError = "subscription was deleted"
+		return d.DB.WithContext(ctx).Omit("Event").Save(delivery).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	statusCode, sendErr := d.send(ctx, subscription, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	switch {
+	case sendErr == nil:
+		delivery.Status = model.DeliveryDelivered
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = model.DeliveryDead
+		delivery.LastError = sendErr.Error()
+		d.Logger.WarnContext(ctx, "webhook delivery is dead", "delivery_id", delivery.ID, "url", subscription.URL, "error", sendErr)
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+	}
+
+	return d.DB.WithContext(ctx).Omit("Event").Save(delivery).Error
+}
+
+// backoff doubles the wait after every failed attempt, up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
+
+// send POSTs the signed event to the subscription URL. Any response other
+// than 2xx is a failure.
+func (d *Dispatcher) send(ctx context.Context, subscription model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(Envelope{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.Type,
+		TenantID:  delivery.Event.TenantID,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      json.RawMessage(delivery.Event.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, delivery.Event.Type)
+	request.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, Sign(subscription.Secret, timestamp, body))
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("subscriber answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
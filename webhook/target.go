@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for a webhook URL, or a connection, to an
+// address of the network the deployment runs in.
+var ErrPrivateAddress = errors.New("webhook target is a private address")
+
+// PrivateAddress reports whether ip is a loopback, link-local, private or
+// unspecified address, which subscribers of other tenants could use to reach
+// the services next to the deployment.
+func PrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// CheckURL returns ErrPrivateAddress if the host of target is localhost or a
+// private IP address. Other host names are checked once resolved, when the
+// delivery is sent by a client of NewClient.
+func CheckURL(target *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && PrivateAddress(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// NewClient returns the HTTP client that sends deliveries. It follows no
+// redirects, which count as failed deliveries, and unless allowPrivate it
+// refuses to connect to private addresses, whatever the host of the URL
+// resolves to at that time.
+func NewClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if ip := net.ParseIP(host); err != nil || ip == nil || PrivateAddress(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Connect to the subscribers directly, so that their addresses are the
+	// ones checked.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}